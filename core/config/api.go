@@ -1,12 +1,22 @@
 package config
 
+import (
+	"fmt"
+
+	"ImageMaster/core/types"
+)
+
 type API struct {
 	manager *Manager
 }
 
 func NewAPI(appName string) *API {
+	return NewAPIWithManager(NewManager(appName))
+}
+
+func NewAPIWithManager(manager *Manager) *API {
 	return &API{
-		manager: NewManager(appName),
+		manager: manager,
 	}
 }
 
@@ -41,3 +51,83 @@ func (a *API) GetLibraries() []string {
 func (a *API) AddLibrary() bool {
 	return a.manager.AddLibrary()
 }
+
+func (a *API) GetGenericCrawlerConfig() types.GenericCrawlerConfig {
+	return a.manager.GetGenericCrawlerConfig()
+}
+
+func (a *API) SetGenericCrawlerConfig(cfg types.GenericCrawlerConfig) bool {
+	return a.manager.SetGenericCrawlerConfig(cfg)
+}
+
+func (a *API) GetExtensionDir(kind string) string {
+	return a.manager.GetExtensionDir(kind)
+}
+
+func (a *API) GetQueueConfig() types.QueueConfig {
+	return a.manager.GetQueueConfig()
+}
+
+func (a *API) SetQueueConfig(cfg types.QueueConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if !a.manager.SetQueueConfig(cfg) {
+		return fmt.Errorf("保存队列配置失败")
+	}
+	return nil
+}
+
+func (a *API) GetValidationConfig() types.ValidationConfig {
+	return a.manager.GetValidationConfig()
+}
+
+func (a *API) SetValidationConfig(cfg types.ValidationConfig) bool {
+	return a.manager.SetValidationConfig(cfg)
+}
+
+func (a *API) GetRetryConfig() types.RetryConfig {
+	return a.manager.GetRetryConfig()
+}
+
+func (a *API) SetRetryConfig(cfg types.RetryConfig) bool {
+	return a.manager.SetRetryConfig(cfg)
+}
+
+func (a *API) GetRateLimitConfig() types.RateLimitConfig {
+	return a.manager.GetRateLimitConfig()
+}
+
+func (a *API) SetRateLimitConfig(cfg types.RateLimitConfig) bool {
+	return a.manager.SetRateLimitConfig(cfg)
+}
+
+func (a *API) GetBandwidthConfig() types.BandwidthConfig {
+	return a.manager.GetBandwidthConfig()
+}
+
+func (a *API) SetBandwidthConfig(cfg types.BandwidthConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if !a.manager.SetBandwidthConfig(cfg) {
+		return fmt.Errorf("保存带宽配置失败")
+	}
+	return nil
+}
+
+func (a *API) GetNamingConfig() types.NamingConfig {
+	return a.manager.GetNamingConfig()
+}
+
+func (a *API) SetNamingConfig(cfg types.NamingConfig) bool {
+	return a.manager.SetNamingConfig(cfg)
+}
+
+func (a *API) GetCollisionPolicy() types.CollisionPolicy {
+	return a.manager.GetCollisionPolicy()
+}
+
+func (a *API) SetCollisionPolicy(policy types.CollisionPolicy) bool {
+	return a.manager.SetCollisionPolicy(policy)
+}
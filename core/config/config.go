@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"ImageMaster/core/logger"
 	"ImageMaster/core/types"
@@ -16,7 +17,28 @@ import (
 var _ types.ConfigProvider = (*Manager)(nil)
 var _ types.ConfigManager = (*Manager)(nil)
 
-var defaultConfig = Config{Libraries: []string{}, OutputDir: "", ProxyURL: "", ActiveLibrary: ""}
+var _ types.GenericConfigProvider = (*Manager)(nil)
+var _ types.ExtensionDirProvider = (*Manager)(nil)
+var _ types.QueueConfigProvider = (*Manager)(nil)
+var _ types.ValidationConfigProvider = (*Manager)(nil)
+var _ types.RetryConfigProvider = (*Manager)(nil)
+var _ types.RateLimitConfigProvider = (*Manager)(nil)
+var _ types.BandwidthConfigProvider = (*Manager)(nil)
+var _ types.NamingConfigProvider = (*Manager)(nil)
+var _ types.CollisionConfigProvider = (*Manager)(nil)
+
+var defaultConfig = Config{
+	Libraries:     []string{},
+	OutputDir:     "",
+	ProxyURL:      "",
+	ActiveLibrary: "",
+	Collision:     types.CollisionMerge,
+	Generic:       types.GenericCrawlerConfig{MinWidth: 200, MinHeight: 200},
+	Queue:         types.QueueConfig{MaxActiveTasks: 3, MaxTasksPerSite: 2},
+	Retry: types.RetryConfig{
+		Default: types.RetryPolicy{MaxRetries: 3, BaseDelayMs: 2000, MaxDelayMs: 60000, Jitter: 0.2},
+	},
+}
 
 // Config 应用配置结构体
 type Config struct {
@@ -24,12 +46,26 @@ type Config struct {
 	OutputDir     string   `json:"output_dir"`
 	ProxyURL      string   `json:"proxy_url"`
 	ActiveLibrary string   `json:"active_library"`
+
+	Generic types.GenericCrawlerConfig `json:"generic"` // 通用爬虫配置
+	Queue   types.QueueConfig          `json:"queue"`   // 下载队列配置
+
+	Validation types.ValidationConfig `json:"validation"` // 下载后图片校验配置
+	Retry      types.RetryConfig      `json:"retry"`      // 下载重试配置
+	RateLimit  types.RateLimitConfig  `json:"rate_limit"` // 按站点的请求速率限制
+	Bandwidth  types.BandwidthConfig  `json:"bandwidth"`  // 全局下载带宽限制
+	Naming     types.NamingConfig     `json:"naming"`     // 保存路径命名模板
+
+	Collision types.CollisionPolicy `json:"collision_policy"` // 画廊目录已存在时的处理方式
 }
 
-// Manager 配置管理器
+// Manager 配置管理器，可并发读取与修改
+// 设置方法整体替换配置中的切片与映射，不原地修改，读取方拿到的值不会被并发改写
 type Manager struct {
-	config     Config
-	configPath string
+	mu           sync.RWMutex
+	config       Config
+	configPath   string
+	queueChanged func() // 队列配置修改后的回调，用于立即重新调度
 }
 
 // NewManager 创建新的配置管理器
@@ -53,6 +89,9 @@ func NewManager(configName string) *Manager {
 
 // LoadConfig 加载应用配置
 func (m *Manager) LoadConfig() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	data, err := os.ReadFile(m.configPath)
 	logger.Debug("Loading config from: %s", m.configPath)
 	if err != nil {
@@ -62,12 +101,15 @@ func (m *Manager) LoadConfig() bool {
 		return false
 	}
 
-	err = json.Unmarshal(data, &m.config)
+	// 解析到新的副本，不修改读取方可能仍在使用的旧配置
+	config := defaultConfig
+	err = json.Unmarshal(data, &config)
 	if err != nil {
 		logger.Error("Failed to parse config: %v, using default config", err)
 		m.config = defaultConfig
 		return false
 	}
+	m.config = config
 
 	logger.Debug("Config loaded successfully")
 	return true
@@ -75,6 +117,13 @@ func (m *Manager) LoadConfig() bool {
 
 // SaveConfig 保存应用配置
 func (m *Manager) SaveConfig() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.saveLocked()
+}
+
+// saveLocked 保存应用配置，调用方需持有写锁
+func (m *Manager) saveLocked() bool {
 	data, err := json.Marshal(m.config)
 	if err != nil {
 		logger.Error("Failed to marshal config: %v", err)
@@ -93,24 +142,32 @@ func (m *Manager) SaveConfig() bool {
 
 // GetConfig 获取配置
 func (m *Manager) GetConfig() Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.config
 }
 
 // SetConfig 设置配置
 func (m *Manager) SetConfig(config Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.config = config
 }
 
 // GetLibraries 获取图书馆列表
 func (m *Manager) GetLibraries() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.config.Libraries
 }
 
 // SetActiveLibrary 设置活动图书馆
 func (m *Manager) SetActiveLibrary(library string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.config.ActiveLibrary = library
 	logger.Info("Set active library: %s", library)
-	return m.SaveConfig()
+	return m.saveLocked()
 }
 
 // AddLibrary 添加图书馆
@@ -122,6 +179,9 @@ func (m *Manager) AddLibrary() bool {
 		return false
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	// 检查是否已经添加过该库
 	for _, lib := range m.config.Libraries {
 		if lib == dir {
@@ -131,13 +191,15 @@ func (m *Manager) AddLibrary() bool {
 	}
 
 	// 添加到配置中
-	m.config.Libraries = append(m.config.Libraries, dir)
+	m.config.Libraries = append(append([]string(nil), m.config.Libraries...), dir)
 	logger.Info("Added library: %s", dir)
-	return m.SaveConfig()
+	return m.saveLocked()
 }
 
 // GetOutputDir 获取输出目录
 func (m *Manager) GetOutputDir() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.config.OutputDir
 }
 
@@ -151,9 +213,8 @@ func (m *Manager) SetOutputDir() bool {
 		return false
 	}
 
-	m.config.OutputDir = dir
-
-	// 更新图书馆管理器的输出目录
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.config.OutputDir = dir
 
 	return true
@@ -161,17 +222,185 @@ func (m *Manager) SetOutputDir() bool {
 
 // GetActiveLibrary 获取活动图书馆
 func (m *Manager) GetActiveLibrary() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.config.ActiveLibrary
 }
 
 // SetProxy 设置代理
 func (m *Manager) SetProxy(proxyURL string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.config.ProxyURL = proxyURL
 	logger.Debug("Set proxy: %s", proxyURL)
-	return m.SaveConfig()
+	return m.saveLocked()
 }
 
 // GetProxy 获取代理设置
 func (m *Manager) GetProxy() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.config.ProxyURL
 }
+
+// GetGenericCrawlerConfig 获取通用爬虫配置
+func (m *Manager) GetGenericCrawlerConfig() types.GenericCrawlerConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.config.Generic
+}
+
+// SetGenericCrawlerConfig 设置通用爬虫配置
+func (m *Manager) SetGenericCrawlerConfig(cfg types.GenericCrawlerConfig) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config.Generic = cfg
+	logger.Debug("Set generic crawler config: %+v", cfg)
+	return m.saveLocked()
+}
+
+// GetQueueConfig 获取下载队列配置
+func (m *Manager) GetQueueConfig() types.QueueConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.config.Queue
+}
+
+// SetQueueConfig 设置下载队列配置，保存后通知任务管理器重新调度
+func (m *Manager) SetQueueConfig(cfg types.QueueConfig) bool {
+	if err := cfg.Validate(); err != nil {
+		logger.Warn("Invalid queue config: %v", err)
+		return false
+	}
+	m.mu.Lock()
+	m.config.Queue = cfg
+	logger.Debug("Set queue config: %+v", cfg)
+	saved := m.saveLocked()
+	queueChanged := m.queueChanged
+	m.mu.Unlock()
+
+	if queueChanged != nil {
+		queueChanged()
+	}
+	return saved
+}
+
+// OnQueueConfigChange 设置队列配置修改后的回调
+func (m *Manager) OnQueueConfigChange(fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queueChanged = fn
+}
+
+// GetValidationConfig 获取图片校验配置
+func (m *Manager) GetValidationConfig() types.ValidationConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.config.Validation
+}
+
+// SetValidationConfig 设置图片校验配置
+func (m *Manager) SetValidationConfig(cfg types.ValidationConfig) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config.Validation = cfg
+	logger.Debug("Set validation config: %+v", cfg)
+	return m.saveLocked()
+}
+
+// GetRetryConfig 获取下载重试配置
+func (m *Manager) GetRetryConfig() types.RetryConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.config.Retry
+}
+
+// SetRetryConfig 设置下载重试配置
+func (m *Manager) SetRetryConfig(cfg types.RetryConfig) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config.Retry = cfg
+	logger.Debug("Set retry config: %+v", cfg)
+	return m.saveLocked()
+}
+
+// GetRateLimitConfig 获取请求速率限制配置
+func (m *Manager) GetRateLimitConfig() types.RateLimitConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.config.RateLimit
+}
+
+// SetRateLimitConfig 设置请求速率限制配置
+func (m *Manager) SetRateLimitConfig(cfg types.RateLimitConfig) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config.RateLimit = cfg
+	logger.Debug("Set rate limit config: %+v", cfg)
+	return m.saveLocked()
+}
+
+// GetBandwidthConfig 获取带宽限制配置
+func (m *Manager) GetBandwidthConfig() types.BandwidthConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.config.Bandwidth
+}
+
+// SetBandwidthConfig 设置带宽限制配置，正在进行的下载立即按新上限限速
+// 时段格式错误时不保存并返回 false
+func (m *Manager) SetBandwidthConfig(cfg types.BandwidthConfig) bool {
+	if err := cfg.Validate(); err != nil {
+		logger.Warn("Invalid bandwidth config: %v", err)
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config.Bandwidth = cfg
+	logger.Debug("Set bandwidth config: %+v", cfg)
+	return m.saveLocked()
+}
+
+// GetNamingConfig 获取命名模板配置
+func (m *Manager) GetNamingConfig() types.NamingConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.config.Naming
+}
+
+// SetNamingConfig 设置命名模板配置，对之后开始的任务生效
+func (m *Manager) SetNamingConfig(cfg types.NamingConfig) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config.Naming = cfg
+	logger.Debug("Set naming config: %+v", cfg)
+	return m.saveLocked()
+}
+
+// GetCollisionPolicy 获取画廊目录已存在时的处理方式，未配置时为合并
+func (m *Manager) GetCollisionPolicy() types.CollisionPolicy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.config.Collision.Valid() {
+		return types.CollisionMerge
+	}
+	return m.config.Collision
+}
+
+// SetCollisionPolicy 设置画廊目录已存在时的处理方式，对之后开始的任务生效
+func (m *Manager) SetCollisionPolicy(policy types.CollisionPolicy) bool {
+	if !policy.Valid() {
+		logger.Warn("Unknown collision policy: %s", policy)
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config.Collision = policy
+	logger.Debug("Set collision policy: %s", policy)
+	return m.saveLocked()
+}
+
+// GetExtensionDir 获取扩展目录，位于配置文件旁，如 imagemaster-rules
+func (m *Manager) GetExtensionDir(kind string) string {
+	return m.configPath + "-" + kind
+}
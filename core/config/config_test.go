@@ -0,0 +1,44 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ImageMaster/core/types"
+	"ImageMaster/core/utils"
+)
+
+// 下载读取带宽配置的同时修改配置，需配合 -race 运行
+func TestSetBandwidthConfigWhileReading(t *testing.T) {
+	m := &Manager{config: defaultConfig, configPath: filepath.Join(t.TempDir(), "config.json")}
+	limiter := utils.NewBandwidthLimiter()
+	limiter.SetLimitFunc(func(now time.Time) int64 {
+		return m.GetBandwidthConfig().LimitAt(now)
+	})
+
+	done := make(chan error)
+	go func() {
+		r := limiter.Reader(context.Background(), bytes.NewReader(make([]byte, 4<<20)))
+		_, err := io.Copy(io.Discard, r)
+		done <- err
+	}()
+
+	for i := 0; ; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			return
+		default:
+		}
+		m.SetBandwidthConfig(types.BandwidthConfig{
+			LimitKBps: 64 * 1024,
+			Schedule:  []types.BandwidthRule{{Start: "00:00", End: "23:59", LimitKBps: int64(32*1024 + i%2)}},
+		})
+	}
+}
@@ -2,12 +2,18 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"sync"
+	"time"
 
+	"ImageMaster/core/crawler/parsers"
 	"ImageMaster/core/download"
+	"ImageMaster/core/logger"
 	"ImageMaster/core/task"
 	"ImageMaster/core/types"
 	"ImageMaster/core/types/dto"
+	"ImageMaster/core/utils"
 )
 
 // CrawlerAPI 爬虫API接口
@@ -45,10 +51,72 @@ func NewCrawlerAPI(configManager types.ConfigProvider, store types.HistoryStore)
 
 	// 设置配置管理器
 	api.taskManager.SetConfigManager(configManager)
+	// 修改队列配置后立即重新调度
+	if notifier, ok := configManager.(interface{ OnQueueConfigChange(func()) }); ok {
+		notifier.OnQueueConfigChange(api.taskManager.Schedule)
+	}
+
+	// 全局带宽限制按实时配置计算，修改配置后正在进行的下载立即生效
+	if provider, ok := configManager.(types.BandwidthConfigProvider); ok {
+		utils.Bandwidth.SetLimitFunc(func(now time.Time) int64 {
+			return provider.GetBandwidthConfig().LimitAt(now)
+		})
+	}
+
+	// 恢复上次暂停的任务
+	if dirs, ok := store.(types.DataDirProvider); ok {
+		api.taskManager.SetStateDir(dirs.GetDataDir())
+	}
+
+	// 加载声明式站点规则、用户脚本与外部插件
+	api.ReloadSiteRules()
+	api.ReloadParserScripts()
+	api.ReloadPlugins()
 
 	return api
 }
 
+// ReloadSiteRules 重新加载配置目录下的站点规则，返回已加载的规则名称
+// 无需重启即可生效；有问题的规则会被跳过并在错误中说明原因
+func (api *CrawlerAPI) ReloadSiteRules() ([]string, error) {
+	dir, err := api.extensionDir(types.ExtensionRules)
+	if err != nil {
+		return nil, err
+	}
+	return parsers.ReloadSiteRules(dir)
+}
+
+// ReloadParserScripts 重新加载配置目录下的 .js 解析脚本，返回已加载的脚本名称
+func (api *CrawlerAPI) ReloadParserScripts() ([]string, error) {
+	dir, err := api.extensionDir(types.ExtensionScripts)
+	if err != nil {
+		return nil, err
+	}
+	return parsers.ReloadParserScripts(dir)
+}
+
+// ReloadPlugins 重新加载配置目录下的外部插件清单，返回已加载的插件名称
+func (api *CrawlerAPI) ReloadPlugins() ([]string, error) {
+	dir, err := api.extensionDir(types.ExtensionPlugins)
+	if err != nil {
+		return nil, err
+	}
+	return parsers.ReloadPlugins(dir)
+}
+
+// extensionDir 获取并确保扩展目录存在
+func (api *CrawlerAPI) extensionDir(kind string) (string, error) {
+	dirs, ok := api.configManager.(types.ExtensionDirProvider)
+	if !ok {
+		return "", fmt.Errorf("配置管理器不支持扩展目录")
+	}
+	dir := dirs.GetExtensionDir(kind)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Warn("创建扩展目录失败 %s: %v", dir, err)
+	}
+	return dir, nil
+}
+
 // SetContext 设置Wails上下文
 func (api *CrawlerAPI) SetContext(ctx context.Context) {
 	api.ctx = ctx
@@ -62,6 +130,71 @@ func (api *CrawlerAPI) StartCrawl(url string) string {
 	return api.taskManager.CrawlWebImages(url)
 }
 
+// StartCrawlWithOptions 按选项开始爬取（如页码选择），返回任务ID
+func (api *CrawlerAPI) StartCrawlWithOptions(url string, options task.StartOptions) (string, error) {
+	t, err := api.taskManager.AddTaskWithOptions(url, options)
+	if err != nil {
+		return "", err
+	}
+	return t.ID, nil
+}
+
+// PreviewCrawl 仅解析网页，返回名称、页数、缩略图等预览信息
+// 解析结果会被缓存，可通过 StartFromPreview 直接开始下载
+func (api *CrawlerAPI) PreviewCrawl(url string, options task.StartOptions) (*task.CrawlPreview, error) {
+	return api.taskManager.PreviewCrawl(url, options)
+}
+
+// PreviewSavePath 解析网页并按当前命名模板返回第一张图片的保存路径，用于检查模板效果
+func (api *CrawlerAPI) PreviewSavePath(url string, options task.StartOptions) (string, error) {
+	return api.taskManager.PreviewSavePath(url, options)
+}
+
+// StartFromPreview 使用预览的解析结果开始下载，返回任务ID
+func (api *CrawlerAPI) StartFromPreview(previewID string, options task.StartOptions) (string, error) {
+	return api.taskManager.StartFromPreview(previewID, options)
+}
+
+// RetryFailed 只重新下载任务中失败的图片
+func (api *CrawlerAPI) RetryFailed(taskID string) error {
+	return api.taskManager.RetryFailed(taskID)
+}
+
+// PauseTask 暂停任务，已下载的图片与未完成的临时文件会保留
+func (api *CrawlerAPI) PauseTask(taskID string) error {
+	return api.taskManager.PauseTask(taskID)
+}
+
+// ResumeTask 继续下载暂停的任务，只下载未完成的图片
+func (api *CrawlerAPI) ResumeTask(taskID string) error {
+	return api.taskManager.ResumeTask(taskID)
+}
+
+// SetTaskPriority 设置任务优先级，数值越大越先执行
+func (api *CrawlerAPI) SetTaskPriority(taskID string, priority int) error {
+	return api.taskManager.SetTaskPriority(taskID, priority)
+}
+
+// MoveTask 将排队中的任务移动到指定位置，从 1 开始
+func (api *CrawlerAPI) MoveTask(taskID string, position int) error {
+	return api.taskManager.MoveTask(taskID, position)
+}
+
+// SetDownloadConcurrency 设置每个任务的并发下载数量，正在下载的任务立即生效
+func (api *CrawlerAPI) SetDownloadConcurrency(n int) error {
+	return api.taskManager.SetDownloadConcurrency(n)
+}
+
+// SetItemPriority 调整任务中尚未开始下载的图片的优先级，数值越大越先下载
+func (api *CrawlerAPI) SetItemPriority(taskID, url string, priority int) error {
+	return api.taskManager.SetItemPriority(taskID, url, priority)
+}
+
+// GetQueuePosition 获取任务的排队位置，从 1 开始；未在排队时返回 0
+func (api *CrawlerAPI) GetQueuePosition(taskID string) int {
+	return api.taskManager.GetQueuePosition(taskID)
+}
+
 // CancelCrawl 取消爬取任务
 func (api *CrawlerAPI) CancelCrawl(taskID string) bool {
 	return api.taskManager.CancelTask(taskID)
@@ -94,6 +227,11 @@ func (api *CrawlerAPI) GetTaskByID(taskID string) *task.DownloadTask {
 	return api.taskManager.GetTaskByID(taskID)
 }
 
+// GetTaskItems 获取任务中每张图片的页码、地址、保存路径、状态、尝试次数、大小与错误
+func (api *CrawlerAPI) GetTaskItems(taskID string) ([]types.DownloadItem, error) {
+	return api.taskManager.GetTaskItems(taskID)
+}
+
 // GetTaskProgress 获取任务进度
 func (api *CrawlerAPI) GetTaskProgress(taskID string) map[string]interface{} {
 	task := api.taskManager.GetTaskByID(taskID)
@@ -107,6 +245,23 @@ func (api *CrawlerAPI) GetTaskProgress(taskID string) map[string]interface{} {
 		"current": task.Progress.Current,
 		"total":   task.Progress.Total,
 		"percent": calculatePercent(task.Progress.Current, task.Progress.Total),
+		// 排队位置，从 1 开始，未排队时为 0
+		"queuePosition": api.taskManager.GetQueuePosition(taskID),
+		// 字节进度、速度（字节/秒）与预计剩余秒数
+		"phase":           task.Phase,
+		"bytesDownloaded": task.BytesDownloaded,
+		"bytesExpected":   task.BytesExpected,
+		"speed":           task.Speed,
+		"eta":             task.ETA,
+	}
+}
+
+// GetGlobalSpeed 获取所有执行中任务的总下载速度
+func (api *CrawlerAPI) GetGlobalSpeed() map[string]interface{} {
+	speed := api.taskManager.GetGlobalSpeed()
+	return map[string]interface{}{
+		"bytesPerSecond": speed,
+		"speed":          utils.FormatSpeed(speed),
 	}
 }
 
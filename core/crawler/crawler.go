@@ -3,7 +3,6 @@ package crawler
 import (
 	"context"
 	"fmt"
-	"net/url"
 
 	"ImageMaster/core/crawler/parsers"
 	"ImageMaster/core/logger"
@@ -64,20 +63,13 @@ func (f *CrawlerFactory) createCrawler(siteType string) types.ImageCrawler {
 }
 
 // DetectSiteType 检测网站类型
-func (f *CrawlerFactory) detectSiteType(rawURL string) string {
-	parsedURL, err := url.Parse(rawURL)
-	if err != nil {
-		return parsers.SiteTypeGeneric
-	}
-
-	host := parsedURL.Host
-
-	// 统一由 parsers 层的 host 注册表识别
-	return parsers.DetectSiteTypeByHost(host)
+func (f *CrawlerFactory) DetectSiteType(rawURL string) string {
+	// 统一由 parsers 层的 host/URL 注册表识别
+	return parsers.DetectSiteType(rawURL)
 }
 
 func (f *CrawlerFactory) Create(rawURL string) (types.ImageCrawler, error) {
-	siteType := f.detectSiteType(rawURL)
+	siteType := f.DetectSiteType(rawURL)
 	crawler := f.createCrawler(siteType)
 	if crawler == nil {
 		return nil, fmt.Errorf("unsupported site type: %s", siteType)
@@ -0,0 +1,66 @@
+package parsers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"ImageMaster/core/download"
+	"ImageMaster/core/types"
+)
+
+// maxRenameSuffix 重命名时尝试的最大序号
+const maxRenameSuffix = 1000
+
+// GalleryExists 判断画廊目录是否已存在且不为空
+func GalleryExists(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) > 0
+}
+
+// ResolveCollision 按处理方式决定画廊目录，不修改文件
+// 目录不存在时返回空的处理方式与原目录；重命名时返回第一个不存在的 "name (N)" 目录
+func ResolveCollision(policy types.CollisionPolicy, contentPath string) (types.CollisionPolicy, string, error) {
+	if !GalleryExists(contentPath) {
+		return "", contentPath, nil
+	}
+	if !policy.Valid() {
+		policy = types.CollisionMerge
+	}
+	if policy != types.CollisionRename {
+		return policy, contentPath, nil
+	}
+	base := strings.TrimRight(contentPath, "/")
+	for n := 2; n <= maxRenameSuffix; n++ {
+		candidate := fmt.Sprintf("%s (%d)", base, n)
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return policy, candidate, nil
+		}
+	}
+	return "", "", fmt.Errorf("无法为已存在的目录生成新名称: %s", contentPath)
+}
+
+// rebasePaths 将画廊目录下的文件路径改到新目录下
+func rebasePaths(filePaths []string, from, to string) []string {
+	if from == to {
+		return filePaths
+	}
+	rebased := make([]string, len(filePaths))
+	for i, p := range filePaths {
+		if rest, ok := strings.CutPrefix(p, from); ok {
+			p = to + rest
+		}
+		rebased[i] = p
+	}
+	return rebased
+}
+
+// removeImages 删除将要重新下载的图片，其余文件保持不变
+func removeImages(filePaths []string) error {
+	for _, p := range filePaths {
+		if err := download.RemoveImage(p); err != nil {
+			return fmt.Errorf("删除已有图片失败: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package parsers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ImageMaster/core/types"
+)
+
+func TestResolveCollision(t *testing.T) {
+	dir := t.TempDir()
+	gallery := filepath.Join(dir, "Gallery")
+
+	// 目录不存在时不处理
+	action, path, err := ResolveCollision(types.CollisionRename, gallery)
+	if err != nil || action != "" || path != gallery {
+		t.Fatalf("missing dir = %q %q %v", action, path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "Gallery (2)"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(gallery, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 空目录视为不存在
+	if action, _, _ := ResolveCollision(types.CollisionSkip, gallery); action != "" {
+		t.Errorf("empty dir action = %q", action)
+	}
+
+	if err := os.WriteFile(filepath.Join(gallery, "001.jpg"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		policy types.CollisionPolicy
+		action types.CollisionPolicy
+		path   string
+	}{
+		{types.CollisionSkip, types.CollisionSkip, gallery},
+		{types.CollisionOverwrite, types.CollisionOverwrite, gallery},
+		{"", types.CollisionMerge, gallery},
+		{types.CollisionRename, types.CollisionRename, gallery + " (3)"}, // (2) 已存在
+	}
+	for _, tt := range tests {
+		action, path, err := ResolveCollision(tt.policy, gallery)
+		if err != nil || action != tt.action || path != tt.path {
+			t.Errorf("ResolveCollision(%q) = %q %q %v, want %q %q", tt.policy, action, path, err, tt.action, tt.path)
+		}
+	}
+
+	files := rebasePaths([]string{gallery + "/001.jpg"}, gallery, gallery+" (3)")
+	if files[0] != gallery+" (3)/001.jpg" {
+		t.Errorf("rebasePaths = %q", files)
+	}
+}
+
+func TestRemoveImages(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"001.png", "001.jpg.part", "002.jpg", "info.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 扩展名修正过的图片与临时文件一并删除，其余文件保留
+	if err := removeImages([]string{filepath.Join(dir, "001.jpg")}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"001.png": false, "001.jpg.part": false, "002.jpg": true, "info.json": true} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+}
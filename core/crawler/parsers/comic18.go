@@ -110,4 +110,5 @@ func init() {
 		return NewComic18Crawler(reqClient)
 	})
 	RegisterHostContains(SiteTypeComic18, "18comic.vip", "18comic.org")
+	request.RegisterRateLimit(SiteTypeComic18, types.RateLimitPolicy{RequestsPerSecond: 2, Burst: 4})
 }
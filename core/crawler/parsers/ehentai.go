@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 
@@ -18,8 +20,9 @@ import (
 
 // EHentaiAlbum EH专辑
 type EHentaiAlbum struct {
-	Name  string
-	Pages []string
+	Name     string
+	Pages    []string
+	Metadata *GalleryMetadata
 }
 
 // EHentaiParser EHentai解析器实现
@@ -35,6 +38,8 @@ type EHentaiParser struct {
 	completedLinks     int
 	mu                 sync.Mutex
 	taskUpdater        types.TaskUpdater
+
+	pages *utils.PageSelection // 页码选择，未选中的页不解析
 }
 
 // SetContext 注入上下文以支持取消
@@ -42,6 +47,11 @@ func (p *EHentaiParser) SetContext(ctx context.Context) {
 	p.ctx = ctx
 }
 
+// SetPageSelection 设置页码选择
+func (p *EHentaiParser) SetPageSelection(pages *utils.PageSelection) {
+	p.pages = pages
+}
+
 // SetDownloader 注入下载器
 func (p *EHentaiParser) SetDownloader(dl types.Downloader) {
 	p.downloader = dl
@@ -92,92 +102,93 @@ func (p *EHentaiParser) Parse(reqClient *request.Client, url string) (*ParseResu
 		return nil, fmt.Errorf("获取专辑失败: %w", err)
 	}
 
-	// 批量下载URL和路径
-	var imgURLs []string
-	var filePaths []string
-	var wg sync.WaitGroup
-
-	// 计算总链接数并设置到结构体属性
-	p.totalImages = 0
-	for _, page := range eHentaiAlbum.Pages {
-		links := ParseLinks(page)
-		p.totalImages += len(links)
+	// 按画廊顺序收集所有图片页链接，页码从 1 开始
+	type ehImageLink struct {
+		pageIdx, linkIdx, pageNumber int
+		url                          string
 	}
+	var allLinks []ehImageLink
+	pageNumber := 0
+	for pageIndex, page := range eHentaiAlbum.Pages {
+		for linkIndex, link := range ParseLinks(page) {
+			pageNumber++
+			// 未选中的页不解析，逐页解析是最耗时的部分
+			if !p.pages.Contains(pageNumber) {
+				continue
+			}
+			allLinks = append(allLinks, ehImageLink{pageIdx: pageIndex, linkIdx: linkIndex, pageNumber: pageNumber, url: link})
+		}
+	}
+
+	// 设置总链接数
+	p.totalImages = len(allLinks)
 
 	// 更新任务名称显示总数
 	if p.taskUpdater != nil {
 		p.taskUpdater.UpdateTaskName(fmt.Sprintf("EHentai - 正在解析图片链接 (0/%d张)", p.totalImages))
 	}
 
-	// 遍历每一页
-	for pageIndex, page := range eHentaiAlbum.Pages {
-		// 循环取消检查
+	// 按链接顺序保存结果，解析失败的留空
+	imgURLs := make([]string, len(allLinks))
+	var wg sync.WaitGroup
+
+	// 并发处理每个链接
+	for i, link := range allLinks {
+		// 启动前取消检查
 		if p.ctx != nil {
 			if err := p.ctx.Err(); err != nil {
 				break
 			}
 		}
-		links := ParseLinks(page)
-
-		// 并发处理每个链接
-		for linkIndex, link := range links {
-			// 启动前取消检查
+		wg.Add(1)
+		go func(idx int, linkURL string) {
+			defer wg.Done()
+			// goroutine 内取消检查
 			if p.ctx != nil {
 				if err := p.ctx.Err(); err != nil {
-					break
+					return
 				}
 			}
-			wg.Add(1)
-			go func(pageIdx, linkIdx int, linkURL string) {
-				defer wg.Done()
-				// goroutine 内取消检查
-				if p.ctx != nil {
-					if err := p.ctx.Err(); err != nil {
-						return
-					}
-				}
-
-				// 解析页面获取真实图片URL
-				imgURL, err := p.parsePageForImage(linkURL)
-				if err != nil {
-					logger.Warn("解析页面失败 %s: %v", linkURL, err)
-				} else {
-					logger.Debug("解析到图片：%s", imgURL)
-
-					// 构建保存文件名
-					filename := fmt.Sprintf("%d_%d.jpg", pageIdx, linkIdx)
 
-					// 线程安全地添加到结果中
-					p.mu.Lock()
-					imgURLs = append(imgURLs, imgURL)
-					filePaths = append(filePaths, filename)
-					p.mu.Unlock()
-				}
+			// 解析页面获取真实图片URL
+			imgURL, err := p.parsePageForImage(linkURL)
+			if err != nil {
+				logger.Warn("解析页面失败 %s: %v", linkURL, err)
+			} else {
+				logger.Debug("解析到图片：%s", imgURL)
+				imgURLs[idx] = imgURL
+			}
 
-				// 更新进度计数器和任务名称
-				p.mu.Lock()
-				p.completedLinks++
-				if p.taskUpdater != nil {
-					p.taskUpdater.UpdateTaskName(fmt.Sprintf("EHentai - 解析图片链接进度 (%d/%d张)", p.completedLinks, p.totalImages))
-				}
-				p.mu.Unlock()
-			}(pageIndex, linkIndex, link)
-		}
+			// 更新进度计数器和任务名称
+			p.mu.Lock()
+			p.completedLinks++
+			if p.taskUpdater != nil {
+				p.taskUpdater.UpdateTaskName(fmt.Sprintf("EHentai - 解析图片链接进度 (%d/%d张)", p.completedLinks, p.totalImages))
+			}
+			p.mu.Unlock()
+		}(i, link.url)
 	}
 
 	// 等待所有并发任务完成
 	wg.Wait()
 
+	// 按画廊顺序整理结果，文件名保留原始位置
+	result := &ParseResult{Name: eHentaiAlbum.Name, Metadata: eHentaiAlbum.Metadata}
+	for i, link := range allLinks {
+		if imgURLs[i] == "" {
+			continue
+		}
+		result.ImageURLs = append(result.ImageURLs, imgURLs[i])
+		result.FilePaths = append(result.FilePaths, fmt.Sprintf("%d_%d.jpg", link.pageIdx, link.linkIdx))
+		result.PageNumbers = append(result.PageNumbers, link.pageNumber)
+	}
+
 	// 解析完成，更新任务名称
 	if p.taskUpdater != nil {
 		p.taskUpdater.UpdateTaskName("EHentai - 解析完成，准备下载")
 	}
 
-	return &ParseResult{
-		Name:      eHentaiAlbum.Name,
-		ImageURLs: imgURLs,
-		FilePaths: filePaths,
-	}, nil
+	return result, nil
 }
 
 // getAlbum 获取整个专辑信息
@@ -212,6 +223,10 @@ func (p *EHentaiParser) getAlbum(url string) (*EHentaiAlbum, error) {
 		return nil, fmt.Errorf("无法获取专辑名称")
 	}
 
+	// 画廊元数据只在第一页
+	metadata := parseEHentaiMetadata(doc)
+	metadata.SourceURL = url
+
 	// 获取所有页面URL
 	pageURLs := []string{url} // 包含当前页面
 
@@ -242,8 +257,9 @@ func (p *EHentaiParser) getAlbum(url string) (*EHentaiAlbum, error) {
 			return nil, err
 		}
 		return &EHentaiAlbum{
-			Name:  albumName,
-			Pages: []string{html},
+			Name:     albumName,
+			Pages:    []string{html},
+			Metadata: metadata,
 		}, nil
 	}
 
@@ -329,11 +345,58 @@ func (p *EHentaiParser) getAlbum(url string) (*EHentaiAlbum, error) {
 	}
 
 	return &EHentaiAlbum{
-		Name:  albumName,
-		Pages: validPages,
+		Name:     albumName,
+		Pages:    validPages,
+		Metadata: metadata,
 	}, nil
 }
 
+// parseEHentaiMetadata 从画廊首页解析标题、分类、发布时间与 #taglist 标签
+func parseEHentaiMetadata(doc *goquery.Document) *GalleryMetadata {
+	metadata := &GalleryMetadata{
+		Title:         strings.TrimSpace(doc.Find("#gn").First().Text()),
+		OriginalTitle: strings.TrimSpace(doc.Find("#gj").First().Text()),
+		Category:      strings.TrimSpace(doc.Find("#gdc .cs").First().Text()),
+	}
+
+	// 左侧信息表：Posted / Language / Length 等
+	doc.Find("#gdd tr").Each(func(i int, s *goquery.Selection) {
+		label := strings.TrimSuffix(strings.TrimSpace(s.Find(".gdt1").Text()), ":")
+		value := strings.TrimSpace(s.Find(".gdt2").Text())
+		switch label {
+		case "Posted":
+			if t, err := time.Parse("2006-01-02 15:04", value); err == nil {
+				metadata.UploadDate = t
+			}
+		case "Language":
+			// 形如 "Chinese  TR"，只取语言名
+			if fields := strings.Fields(value); len(fields) > 0 {
+				metadata.Language = strings.ToLower(fields[0])
+			}
+		case "Length":
+			if fields := strings.Fields(value); len(fields) > 0 {
+				metadata.PageCount, _ = strconv.Atoi(fields[0])
+			}
+		}
+	})
+
+	// 标签表：每行第一列为命名空间，如 "artist:"
+	doc.Find("#taglist tr").Each(func(i int, s *goquery.Selection) {
+		namespace := strings.TrimSuffix(strings.TrimSpace(s.Find("td.tc").Text()), ":")
+		s.Find("td div a").Each(func(j int, a *goquery.Selection) {
+			tag := strings.TrimSpace(a.Text())
+			// 带有别名的标签显示为 "name | alias"
+			if name, _, found := strings.Cut(tag, " | "); found {
+				tag = name
+			}
+			metadata.addNamespacedTag(namespace, tag)
+		})
+	})
+
+	metadata.Manga = mangaForCategory(metadata.Category)
+	return metadata
+}
+
 // parsePageForImage 解析EH页面获取真实图片URL
 func (p *EHentaiParser) parsePageForImage(link string) (string, error) {
 	realURL, err := p.getRealURL(link)
@@ -486,6 +549,9 @@ func init() {
 	// host 规则
 	RegisterHostContains(SiteTypeEHentai, "e-hentai.org")
 	RegisterHostContains(SiteTypeExHentai, "exhentai.org")
+	// E-Hentai 对短时间内的大量请求会封禁 IP
+	request.RegisterRateLimit(SiteTypeEHentai, types.RateLimitPolicy{RequestsPerSecond: 1, Burst: 3})
+	request.RegisterRateLimit(SiteTypeExHentai, types.RateLimitPolicy{RequestsPerSecond: 1, Burst: 3})
 }
 
 // SetupEHentaiClient 设置EHentai特殊的客户端配置
@@ -0,0 +1,373 @@
+package parsers
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"ImageMaster/core/logger"
+	"ImageMaster/core/request"
+	"ImageMaster/core/types"
+	"ImageMaster/core/utils"
+)
+
+const (
+	genericProbeConcurrency = 8         // 探测图片尺寸时的并发数
+	genericProbeBytes       = 64 * 1024 // 探测图片尺寸时最多读取的字节数
+)
+
+// genericImageExts 通用爬虫识别的图片扩展名
+var genericImageExts = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true,
+	".webp": true, ".bmp": true, ".avif": true,
+}
+
+// cssURLPattern 匹配 CSS 中 background-image 的 url(...)
+var cssURLPattern = regexp.MustCompile(`background(?:-image)?\s*:[^;{}]*?url\(\s*['"]?([^'")]+)['"]?\s*\)`)
+
+// genericCandidate 页面中收集到的候选图片
+type genericCandidate struct {
+	URL    string
+	Width  int // 来自 width 属性，0 表示未知
+	Height int // 来自 height 属性，0 表示未知
+}
+
+// GenericParser 通用网页解析器
+// 从任意网页中收集图片，作为未适配站点的兜底方案
+type GenericParser struct {
+	config types.GenericCrawlerConfig
+	ctx    context.Context
+}
+
+// NewGenericParser 创建通用解析器
+func NewGenericParser(cfg types.GenericCrawlerConfig) *GenericParser {
+	return &GenericParser{config: cfg}
+}
+
+// SetContext 注入上下文以支持取消
+func (p *GenericParser) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
+// GetName 获取解析器名称
+func (p *GenericParser) GetName() string {
+	return "Generic"
+}
+
+// Parse 解析URL获取图片信息
+func (p *GenericParser) Parse(reqClient *request.Client, pageURL string) (*ParseResult, error) {
+	baseURL, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的URL: %w", err)
+	}
+
+	resp, err := reqClient.Get(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP状态码错误: %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 页面声明了 <base> 时以其为相对路径基准
+	if href, exists := doc.Find("base[href]").First().Attr("href"); exists {
+		if b, err := baseURL.Parse(href); err == nil {
+			baseURL = b
+		}
+	}
+
+	name := genericTitle(doc, baseURL)
+	candidates := collectGenericImages(doc, baseURL)
+	logger.Info("通用解析器在页面中发现 %d 张候选图片", len(candidates))
+
+	candidates, err = p.filterCandidates(reqClient, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("未找到符合条件的图片")
+	}
+
+	imgURLs := make([]string, 0, len(candidates))
+	filePaths := make([]string, 0, len(candidates))
+	for i, c := range candidates {
+		imgURLs = append(imgURLs, c.URL)
+		filePaths = append(filePaths, fmt.Sprintf("%03d%s", i+1, genericExt(c.URL)))
+	}
+
+	return &ParseResult{
+		Name:      name,
+		ImageURLs: imgURLs,
+		FilePaths: filePaths,
+	}, nil
+}
+
+// collectGenericImages 按文档顺序收集页面中的图片地址，解析相对路径并去重
+func collectGenericImages(doc *goquery.Document, baseURL *url.URL) []genericCandidate {
+	var candidates []genericCandidate
+	seen := make(map[string]bool)
+
+	add := func(raw string, width, height int) {
+		abs := resolveImageURL(baseURL, raw)
+		if abs == "" || seen[abs] {
+			return
+		}
+		seen[abs] = true
+		candidates = append(candidates, genericCandidate{URL: abs, Width: width, Height: height})
+	}
+
+	doc.Find("img, source, a[href], [style], style").Each(func(i int, s *goquery.Selection) {
+		switch goquery.NodeName(s) {
+		case "img":
+			width := atoiAttr(s, "width")
+			height := atoiAttr(s, "height")
+			// 懒加载属性通常指向原图，优先于 src（src 往往是占位图）
+			for _, attr := range []string{"data-original", "data-src"} {
+				if v, ok := s.Attr(attr); ok {
+					add(v, width, height)
+				}
+			}
+			if v, ok := s.Attr("srcset"); ok {
+				add(largestFromSrcset(v), 0, 0)
+			}
+			if v, ok := s.Attr("src"); ok {
+				add(v, width, height)
+			}
+		case "source":
+			if v, ok := s.Attr("srcset"); ok {
+				add(largestFromSrcset(v), 0, 0)
+			}
+		case "a":
+			if v, ok := s.Attr("href"); ok && isImagePath(v) {
+				add(v, 0, 0)
+			}
+		case "style":
+			for _, m := range cssURLPattern.FindAllStringSubmatch(s.Text(), -1) {
+				add(m[1], 0, 0)
+			}
+		}
+
+		// 任何元素的内联样式都可能包含背景图
+		if style, ok := s.Attr("style"); ok {
+			for _, m := range cssURLPattern.FindAllStringSubmatch(style, -1) {
+				add(m[1], 0, 0)
+			}
+		}
+	})
+
+	return candidates
+}
+
+// filterCandidates 按配置的最小尺寸/大小过滤候选图片，上下文取消时返回 ctx.Err()
+func (p *GenericParser) filterCandidates(reqClient *request.Client, candidates []genericCandidate) ([]genericCandidate, error) {
+	cfg := p.config
+	if cfg.MinWidth <= 0 && cfg.MinHeight <= 0 && cfg.MinBytes <= 0 {
+		return candidates, nil
+	}
+
+	keep := make([]bool, len(candidates))
+	sem := utils.NewSemaphore(genericProbeConcurrency)
+	var wg sync.WaitGroup
+
+	for i, c := range candidates {
+		// 已知尺寸且不需要检查大小时无需发请求
+		if c.Width > 0 && c.Height > 0 && cfg.MinBytes <= 0 {
+			keep[i] = c.Width >= cfg.MinWidth && c.Height >= cfg.MinHeight
+			continue
+		}
+
+		if p.ctx != nil {
+			if err := sem.AcquireWithContext(p.ctx); err != nil {
+				wg.Wait()
+				return nil, err
+			}
+		} else {
+			sem.Acquire()
+		}
+		wg.Add(1)
+		go func(idx int, c genericCandidate) {
+			defer wg.Done()
+			defer sem.Release()
+			keep[idx] = p.probeCandidate(reqClient, c)
+		}(i, c)
+	}
+	wg.Wait()
+	// 取消后探测失败的图片会被保留，结果不可信
+	if p.ctx != nil && p.ctx.Err() != nil {
+		return nil, p.ctx.Err()
+	}
+
+	var filtered []genericCandidate
+	for i, c := range candidates {
+		if keep[i] {
+			filtered = append(filtered, c)
+		}
+	}
+	logger.Debug("通用解析器过滤后剩余 %d/%d 张图片", len(filtered), len(candidates))
+	return filtered, nil
+}
+
+// probeCandidate 读取图片头部判断尺寸与大小，探测失败时保留该图片
+func (p *GenericParser) probeCandidate(reqClient *request.Client, c genericCandidate) bool {
+	cfg := p.config
+
+	resp, err := reqClient.Get(c.URL)
+	if err != nil {
+		logger.Debug("探测图片失败 %s: %v", c.URL, err)
+		return true
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	if cfg.MinBytes > 0 && resp.ContentLength >= 0 && resp.ContentLength < cfg.MinBytes {
+		return false
+	}
+
+	width, height := c.Width, c.Height
+	if width == 0 || height == 0 {
+		imgCfg, _, err := image.DecodeConfig(io.LimitReader(resp.Body, genericProbeBytes))
+		if err != nil {
+			// 无法识别的格式（如 webp、avif）不做尺寸过滤
+			return true
+		}
+		width, height = imgCfg.Width, imgCfg.Height
+	}
+
+	return width >= cfg.MinWidth && height >= cfg.MinHeight
+}
+
+// genericTitle 获取页面标题作为专辑名称
+func genericTitle(doc *goquery.Document, baseURL *url.URL) string {
+	title, _ := doc.Find(`meta[property="og:title"]`).Attr("content")
+	if strings.TrimSpace(title) == "" {
+		title = doc.Find("title").First().Text()
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = baseURL.Hostname()
+	}
+	title = strings.ReplaceAll(title, "/", "_")
+	return strings.ReplaceAll(title, "\\", "_")
+}
+
+// resolveImageURL 将页面中的地址解析为绝对地址，非 http(s) 地址返回空串
+func resolveImageURL(baseURL *url.URL, raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.HasPrefix(raw, "data:") || strings.HasPrefix(raw, "#") {
+		return ""
+	}
+	u, err := baseURL.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	u.Fragment = ""
+	return u.String()
+}
+
+// largestFromSrcset 从 srcset 中选出分辨率最高的地址
+func largestFromSrcset(srcset string) string {
+	best := ""
+	bestScore := -1.0
+	for _, entry := range strings.Split(srcset, ",") {
+		fields := strings.Fields(strings.TrimSpace(entry))
+		if len(fields) == 0 {
+			continue
+		}
+		score := 1.0
+		if len(fields) > 1 {
+			descriptor := fields[len(fields)-1]
+			if n, err := strconv.ParseFloat(descriptor[:len(descriptor)-1], 64); err == nil {
+				score = n
+			}
+		}
+		if score > bestScore {
+			best, bestScore = fields[0], score
+		}
+	}
+	return best
+}
+
+// isImagePath 判断链接是否指向图片文件
+func isImagePath(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return genericImageExts[strings.ToLower(path.Ext(u.Path))]
+}
+
+// genericExt 从图片地址推断扩展名，无法推断时使用 .jpg
+func genericExt(raw string) string {
+	u, err := url.Parse(raw)
+	if err == nil {
+		ext := strings.ToLower(path.Ext(u.Path))
+		if genericImageExts[ext] {
+			return ext
+		}
+	}
+	return ".jpg"
+}
+
+// atoiAttr 读取整数属性，失败返回 0
+func atoiAttr(s *goquery.Selection, name string) int {
+	v, ok := s.Attr(name)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(v), "px"))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// GenericCrawler 通用网页爬虫
+type GenericCrawler struct {
+	*BaseCrawler
+}
+
+// NewGenericCrawler 创建通用网页爬虫
+func NewGenericCrawler(reqClient *request.Client, cfg types.GenericCrawlerConfig) types.ImageCrawler {
+	parser := NewGenericParser(cfg)
+	baseCrawler := NewBaseCrawler(reqClient, parser)
+	return &GenericCrawler{
+		BaseCrawler: baseCrawler,
+	}
+}
+
+// 插件注册
+func init() {
+	// 通用爬虫不注册 host 规则，由 DetectSiteTypeByHost 兜底返回
+	Register(SiteTypeGeneric, func(reqClient *request.Client, cfg types.ConfigProvider) types.ImageCrawler {
+		var genericCfg types.GenericCrawlerConfig
+		if provider, ok := cfg.(types.GenericConfigProvider); ok {
+			genericCfg = provider.GetGenericCrawlerConfig()
+		}
+		return NewGenericCrawler(reqClient, genericCfg)
+	})
+}
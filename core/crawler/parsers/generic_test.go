@@ -0,0 +1,87 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"ImageMaster/core/request"
+	"ImageMaster/core/types"
+)
+
+func TestCollectGenericImages(t *testing.T) {
+	html := `<html><head>
+<style>.hero { background-image: url("/img/hero.png"); }</style>
+</head><body>
+<img src="placeholder.gif" data-src="/img/001.jpg" width="800" height="1200">
+<img src="https://cdn.example.com/img/002.png">
+<img srcset="/img/003-small.jpg 480w, /img/003-large.jpg 1920w">
+<a href="/img/004.webp#top">full</a>
+<a href="/page/2">next</a>
+<div style="background: #000 url('img/005.jpg') no-repeat"></div>
+<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">
+<img data-original="/img/001.jpg">
+</body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("parse html: %v", err)
+	}
+	base, _ := url.Parse("https://example.com/gallery/index.html")
+
+	got := collectGenericImages(doc, base)
+	expected := []string{
+		"https://example.com/img/hero.png",
+		"https://example.com/img/001.jpg",
+		"https://example.com/gallery/placeholder.gif",
+		"https://cdn.example.com/img/002.png",
+		"https://example.com/img/003-large.jpg",
+		"https://example.com/img/004.webp",
+		"https://example.com/gallery/img/005.jpg",
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("collectGenericImages() returned %d images, expected %d: %+v", len(got), len(expected), got)
+	}
+	for i, c := range got {
+		if c.URL != expected[i] {
+			t.Errorf("image[%d] = %q, expected %q", i, c.URL, expected[i])
+		}
+	}
+	if got[1].Width != 800 || got[1].Height != 1200 {
+		t.Errorf("image[1] size = %dx%d, expected 800x1200", got[1].Width, got[1].Height)
+	}
+}
+
+func TestLargestFromSrcset(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a.jpg 1x, b.jpg 2x", "b.jpg"},
+		{"a.jpg 1920w, b.jpg 480w", "a.jpg"},
+		{"only.jpg", "only.jpg"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := largestFromSrcset(tt.input); got != tt.expected {
+			t.Errorf("largestFromSrcset(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestFilterCandidatesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	parser := NewGenericParser(types.GenericCrawlerConfig{MinBytes: 1024})
+	parser.SetContext(ctx)
+	candidates := []genericCandidate{{URL: "http://127.0.0.1:1/a.jpg"}, {URL: "http://127.0.0.1:1/b.jpg"}}
+	if filtered, err := parser.filterCandidates(request.NewClient(), candidates); !errors.Is(err, context.Canceled) {
+		t.Errorf("filterCandidates() = %v, %v, want context.Canceled", filtered, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/robertkrimen/otto"
 
@@ -25,9 +26,80 @@ type HitomiFile struct {
 
 // HitomiGalleryInfo 表示 Hitomi 的画廊信息
 type HitomiGalleryInfo struct {
-	Files []HitomiFile `json:"files"`
-	ID    string       `json:"id"`
-	Title string       `json:"title"`
+	Files         []HitomiFile `json:"files"`
+	ID            string       `json:"id"`
+	Title         string       `json:"title"`
+	JapaneseTitle string       `json:"japanese_title"`
+	Language      string       `json:"language"`
+	Type          string       `json:"type"`
+	Date          string       `json:"date"`
+	Artists       []struct {
+		Artist string `json:"artist"`
+	} `json:"artists"`
+	Groups []struct {
+		Group string `json:"group"`
+	} `json:"groups"`
+	Parodys []struct {
+		Parody string `json:"parody"`
+	} `json:"parodys"`
+	Characters []struct {
+		Character string `json:"character"`
+	} `json:"characters"`
+	Tags []struct {
+		Tag    string     `json:"tag"`
+		Female hitomiFlag `json:"female"`
+		Male   hitomiFlag `json:"male"`
+	} `json:"tags"`
+}
+
+// hitomiFlag galleryinfo 中的布尔标记，可能是 "1"、1 或空串
+type hitomiFlag bool
+
+// UnmarshalJSON 兼容字符串与数字两种形式
+func (f *hitomiFlag) UnmarshalJSON(data []byte) error {
+	v := strings.Trim(string(data), `"`)
+	*f = hitomiFlag(v == "1" || v == "true")
+	return nil
+}
+
+// metadata 转换为画廊元数据
+func (g *HitomiGalleryInfo) metadata() *GalleryMetadata {
+	metadata := &GalleryMetadata{
+		Title:         g.Title,
+		OriginalTitle: g.JapaneseTitle,
+		Language:      g.Language,
+		Category:      g.Type,
+		PageCount:     len(g.Files),
+		Extra:         map[string]string{"galleryId": g.ID},
+	}
+	// 形如 "2023-01-02 03:04:00-05"
+	if t, err := time.Parse("2006-01-02 15:04:05-07", g.Date); err == nil {
+		metadata.UploadDate = t
+	}
+	for _, a := range g.Artists {
+		metadata.addNamespacedTag("artist", a.Artist)
+	}
+	for _, gr := range g.Groups {
+		metadata.addNamespacedTag("group", gr.Group)
+	}
+	for _, p := range g.Parodys {
+		metadata.addNamespacedTag("parody", p.Parody)
+	}
+	for _, c := range g.Characters {
+		metadata.addNamespacedTag("character", c.Character)
+	}
+	for _, t := range g.Tags {
+		switch {
+		case bool(t.Female):
+			metadata.addNamespacedTag("female", t.Tag)
+		case bool(t.Male):
+			metadata.addNamespacedTag("male", t.Tag)
+		default:
+			metadata.addNamespacedTag("tag", t.Tag)
+		}
+	}
+	metadata.Manga = mangaForCategory(metadata.Category)
+	return metadata
 }
 
 // HitomiCrawler Hitomi网站爬虫
@@ -50,6 +122,7 @@ func init() {
 		return NewHitomiCrawler(reqClient)
 	})
 	RegisterHostContains(SiteTypeHitomi, "hitomi.la")
+	request.RegisterRateLimit(SiteTypeHitomi, types.RateLimitPolicy{RequestsPerSecond: 4, Burst: 8})
 }
 
 // SetDownloader 设置下载器，自动包装为HitomiDownloader
@@ -112,10 +185,14 @@ func (p *HitomiParser) Parse(reqClient *request.Client, url string) (*ParseResul
 		filePaths[i] = fmt.Sprintf("%03d.%s", i+1, ext)
 	}
 
+	metadata := galleryInfo.metadata()
+	metadata.SourceURL = url
+
 	return &ParseResult{
 		Name:      title,
 		ImageURLs: imageURLs,
 		FilePaths: filePaths,
+		Metadata:  metadata,
 	}, nil
 }
 
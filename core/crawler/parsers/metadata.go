@@ -0,0 +1,216 @@
+package parsers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ImageMaster/core/utils"
+)
+
+// 元数据文件名
+const (
+	ComicInfoFileName = "ComicInfo.xml"
+	InfoJSONFileName  = "info.json"
+)
+
+// GalleryMetadata 画廊元数据，由各解析器尽量填写
+type GalleryMetadata struct {
+	Title         string            `json:"title"`                   // 标题
+	OriginalTitle string            `json:"originalTitle,omitempty"` // 原文标题（如日文标题）
+	Artists       []string          `json:"artists,omitempty"`       // 作者
+	Groups        []string          `json:"groups,omitempty"`        // 社团
+	Parodies      []string          `json:"parodies,omitempty"`      // 原作
+	Characters    []string          `json:"characters,omitempty"`    // 角色
+	Tags          []string          `json:"tags,omitempty"`          // 标签，可带命名空间，如 female:glasses
+	Language      string            `json:"language,omitempty"`      // 语言
+	Category      string            `json:"category,omitempty"`      // 分类
+	PageCount     int               `json:"pageCount,omitempty"`     // 画廊总页数
+	UploadDate    time.Time         `json:"uploadDate,omitzero"`     // 上传时间
+	SourceURL     string            `json:"sourceUrl,omitempty"`     // 来源地址
+	Manga         string            `json:"manga,omitempty"`         // 是否为漫画及阅读方向，取值同 ComicInfo 的 Manga，未知时留空
+	Extra         map[string]string `json:"extra,omitempty"`         // 其他信息
+}
+
+// comicInfo ComicInfo.xml 结构（ComicRack / Anansi v2.0 Schema）
+type comicInfo struct {
+	XMLName     xml.Name `xml:"ComicInfo"`
+	XMLNSXSI    string   `xml:"xmlns:xsi,attr"`
+	XMLNSXSD    string   `xml:"xmlns:xsd,attr"`
+	Title       string   `xml:"Title,omitempty"`
+	Series      string   `xml:"Series,omitempty"`
+	AltSeries   string   `xml:"AlternateSeries,omitempty"`
+	Notes       string   `xml:"Notes,omitempty"`
+	Year        int      `xml:"Year,omitempty"`
+	Month       int      `xml:"Month,omitempty"`
+	Day         int      `xml:"Day,omitempty"`
+	Writer      string   `xml:"Writer,omitempty"`
+	Penciller   string   `xml:"Penciller,omitempty"`
+	Genre       string   `xml:"Genre,omitempty"`
+	Tags        string   `xml:"Tags,omitempty"`
+	Web         string   `xml:"Web,omitempty"`
+	PageCount   int      `xml:"PageCount,omitempty"`
+	LanguageISO string   `xml:"LanguageISO,omitempty"`
+	Characters  string   `xml:"Characters,omitempty"`
+	Teams       string   `xml:"Teams,omitempty"`
+	SeriesGroup string   `xml:"SeriesGroup,omitempty"`
+	Manga       string   `xml:"Manga,omitempty"`
+}
+
+// languageISO 常见语言名称到 ISO 639-1 代码的映射
+var languageISO = map[string]string{
+	"english":    "en",
+	"japanese":   "ja",
+	"chinese":    "zh",
+	"korean":     "ko",
+	"spanish":    "es",
+	"french":     "fr",
+	"german":     "de",
+	"russian":    "ru",
+	"portuguese": "pt",
+	"italian":    "it",
+	"thai":       "th",
+	"vietnamese": "vi",
+	"indonesian": "id",
+	"polish":     "pl",
+	"中文":         "zh",
+	"日语":         "ja",
+	"英语":         "en",
+	"韩语":         "ko",
+}
+
+// ComicInfo 中 Manga 字段的取值
+const (
+	MangaNo             = "No"
+	MangaYes            = "Yes"
+	MangaYesRightToLeft = "YesAndRightToLeft"
+)
+
+// categoryManga 常见分类到阅读方向的映射，未列出的分类视为未知
+var categoryManga = map[string]string{
+	"doujinshi": MangaYesRightToLeft,
+	"manga":     MangaYesRightToLeft,
+	"western":   MangaNo,
+	"同人誌":       MangaYesRightToLeft,
+	"同人志":       MangaYesRightToLeft,
+	"單行本":       MangaYesRightToLeft,
+	"单行本":       MangaYesRightToLeft,
+	"韓漫":        MangaYes,
+	"韩漫":        MangaYes,
+}
+
+// mangaForCategory 按分类推断 Manga 字段，分类形如 "同人誌 / 漢化" 时只看第一段
+func mangaForCategory(category string) string {
+	category, _, _ = strings.Cut(category, "/")
+	return categoryManga[strings.ToLower(strings.TrimSpace(category))]
+}
+
+// toComicInfo 转换为 ComicInfo.xml 结构，pageCount 为实际下载的图片数量
+func (m *GalleryMetadata) toComicInfo(pageCount int) *comicInfo {
+	info := &comicInfo{
+		XMLNSXSI:    "http://www.w3.org/2001/XMLSchema-instance",
+		XMLNSXSD:    "http://www.w3.org/2001/XMLSchema",
+		Title:       m.Title,
+		Series:      m.Title,
+		AltSeries:   m.OriginalTitle,
+		Writer:      strings.Join(m.Artists, ", "),
+		Penciller:   strings.Join(m.Artists, ", "),
+		Genre:       m.Category,
+		Tags:        strings.Join(m.Tags, ", "),
+		Web:         m.SourceURL,
+		PageCount:   pageCount,
+		LanguageISO: languageISO[strings.ToLower(strings.TrimSpace(m.Language))],
+		Characters:  strings.Join(m.Characters, ", "),
+		Teams:       strings.Join(m.Groups, ", "),
+		SeriesGroup: strings.Join(m.Parodies, ", "),
+		Manga:       m.Manga,
+	}
+	if !m.UploadDate.IsZero() {
+		info.Year = m.UploadDate.Year()
+		info.Month = int(m.UploadDate.Month())
+		info.Day = m.UploadDate.Day()
+	}
+	if info.LanguageISO == "" && m.Language != "" {
+		info.Notes = "Language: " + m.Language
+	}
+	return info
+}
+
+// WriteGalleryMetadata 将元数据写入画廊目录下的 ComicInfo.xml 与 info.json
+func WriteGalleryMetadata(dir string, meta *GalleryMetadata, pageCount int) error {
+	dir = utils.NormalizePath(dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	comicXML, err := xml.MarshalIndent(meta.toComicInfo(pageCount), "", "  ")
+	if err != nil {
+		return err
+	}
+	comicXML = append([]byte(xml.Header), comicXML...)
+	if err := os.WriteFile(filepath.Join(dir, ComicInfoFileName), comicXML, 0644); err != nil {
+		return err
+	}
+
+	infoJSON, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, InfoJSONFileName), infoJSON, 0644)
+}
+
+// appendUnique 追加不重复的非空字符串
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		exists := false
+		for _, item := range list {
+			if item == v {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// addNamespacedTag 按命名空间将标签归入对应字段，其他命名空间保留前缀放入 Tags
+func (m *GalleryMetadata) addNamespacedTag(namespace, tag string) {
+	namespace = strings.ToLower(strings.TrimSpace(namespace))
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return
+	}
+	switch namespace {
+	case "artist", "artists":
+		m.Artists = appendUnique(m.Artists, tag)
+	case "group", "groups":
+		m.Groups = appendUnique(m.Groups, tag)
+	case "parody", "parodies":
+		m.Parodies = appendUnique(m.Parodies, tag)
+	case "character", "characters":
+		m.Characters = appendUnique(m.Characters, tag)
+	case "language", "languages":
+		// 翻译标记（translated/rewrite）不是语言
+		if m.Language == "" && tag != "translated" && tag != "rewrite" {
+			m.Language = tag
+		}
+	case "category", "categories":
+		if m.Category == "" {
+			m.Category = tag
+		}
+	case "", "tag", "tags", "misc":
+		m.Tags = appendUnique(m.Tags, tag)
+	default:
+		m.Tags = appendUnique(m.Tags, namespace+":"+tag)
+	}
+}
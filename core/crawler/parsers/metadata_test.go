@@ -0,0 +1,113 @@
+package parsers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestGalleryMetadataNamespacedTags(t *testing.T) {
+	meta := &GalleryMetadata{}
+	meta.addNamespacedTag("artist", "someone")
+	meta.addNamespacedTag("Artist", "someone")
+	meta.addNamespacedTag("group", "circle")
+	meta.addNamespacedTag("language", "translated")
+	meta.addNamespacedTag("language", "chinese")
+	meta.addNamespacedTag("female", "glasses")
+	meta.addNamespacedTag("", "full color")
+
+	if len(meta.Artists) != 1 || meta.Artists[0] != "someone" {
+		t.Errorf("artists = %v", meta.Artists)
+	}
+	if len(meta.Groups) != 1 || meta.Groups[0] != "circle" {
+		t.Errorf("groups = %v", meta.Groups)
+	}
+	if meta.Language != "chinese" {
+		t.Errorf("language = %q", meta.Language)
+	}
+	if strings.Join(meta.Tags, "|") != "female:glasses|full color" {
+		t.Errorf("tags = %v", meta.Tags)
+	}
+}
+
+func TestWriteGalleryMetadata(t *testing.T) {
+	dir := t.TempDir()
+	meta := &GalleryMetadata{
+		Title:      "Test Gallery",
+		Artists:    []string{"a", "b"},
+		Tags:       []string{"female:glasses"},
+		Language:   "Japanese",
+		PageCount:  30,
+		UploadDate: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+		SourceURL:  "https://example.com/g/1",
+	}
+	if err := WriteGalleryMetadata(dir, meta, 20); err != nil {
+		t.Fatalf("WriteGalleryMetadata: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ComicInfoFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info comicInfo
+	if err := xml.Unmarshal(data, &info); err != nil {
+		t.Fatalf("invalid ComicInfo.xml: %v", err)
+	}
+	if info.Title != "Test Gallery" || info.Writer != "a, b" || info.LanguageISO != "ja" ||
+		info.PageCount != 20 || info.Year != 2024 || info.Month != 3 || info.Day != 5 || info.Manga != "" {
+		t.Errorf("unexpected ComicInfo: %+v", info)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, InfoJSONFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded GalleryMetadata
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid info.json: %v", err)
+	}
+	if decoded.PageCount != 30 || decoded.SourceURL != meta.SourceURL || !decoded.UploadDate.Equal(meta.UploadDate) {
+		t.Errorf("unexpected info.json: %+v", decoded)
+	}
+}
+
+func TestParseEHentaiMetadata(t *testing.T) {
+	html := `<html><body>
+<h1 id="gn">Title EN</h1><h1 id="gj">タイトル</h1>
+<div id="gdc"><div class="cs ct2">Doujinshi</div></div>
+<div id="gdd"><table>
+<tr><td class="gdt1">Posted:</td><td class="gdt2">2024-03-05 12:34</td></tr>
+<tr><td class="gdt1">Language:</td><td class="gdt2">Chinese &nbsp;<span class="halp">TR</span></td></tr>
+<tr><td class="gdt1">Length:</td><td class="gdt2">42 pages</td></tr>
+</table></div>
+<div id="taglist"><table>
+<tr><td class="tc">language:</td><td><div><a>chinese</a></div><div><a>translated</a></div></td></tr>
+<tr><td class="tc">artist:</td><td><div><a>someone</a></div></td></tr>
+<tr><td class="tc">female:</td><td><div><a>glasses</a></div></td></tr>
+</table></div>
+</body></html>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta := parseEHentaiMetadata(doc)
+	if meta.Title != "Title EN" || meta.OriginalTitle != "タイトル" || meta.Category != "Doujinshi" || meta.Manga != MangaYesRightToLeft {
+		t.Errorf("unexpected titles/category: %+v", meta)
+	}
+	if meta.Language != "chinese" || meta.PageCount != 42 {
+		t.Errorf("unexpected language/page count: %q %d", meta.Language, meta.PageCount)
+	}
+	if !meta.UploadDate.Equal(time.Date(2024, 3, 5, 12, 34, 0, 0, time.UTC)) {
+		t.Errorf("unexpected upload date: %v", meta.UploadDate)
+	}
+	if strings.Join(meta.Artists, ",") != "someone" || strings.Join(meta.Tags, ",") != "female:glasses" {
+		t.Errorf("unexpected tags: %v %v", meta.Artists, meta.Tags)
+	}
+}
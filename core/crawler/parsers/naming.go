@@ -0,0 +1,103 @@
+package parsers
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ImageMaster/core/naming"
+	"ImageMaster/core/types"
+)
+
+// defaultImageExt 无法从地址确定扩展名时使用，下载完成后会按实际格式修正
+const defaultImageExt = ".jpg"
+
+// SavePaths 生成画廊目录与每张图片的保存路径
+// 未配置模板时沿用原有规则：目录为画廊名称，文件名为解析器提供的文件名或三位页码
+func (r *ParseResult) SavePaths(savePath, siteType string, templates types.NamingTemplates) (string, []string, error) {
+	if templates.Folder == "" && templates.File == "" {
+		return r.defaultSavePaths(savePath)
+	}
+
+	gallery := r.namingGallery(siteType)
+	folder, err := naming.RenderFolder(templates.Folder, gallery)
+	if err != nil {
+		return "", nil, fmt.Errorf("目录命名模板错误: %w", err)
+	}
+	contentPath := savePath + "/" + folder
+
+	filePaths := make([]string, len(r.ImageURLs))
+	for i := range r.ImageURLs {
+		file, err := naming.RenderFile(templates.File, gallery, r.namingPage(i))
+		if err != nil {
+			return "", nil, fmt.Errorf("文件命名模板错误: %w", err)
+		}
+		filePaths[i] = contentPath + "/" + file
+	}
+	return contentPath, filePaths, nil
+}
+
+// defaultSavePaths 原有的保存路径规则
+func (r *ParseResult) defaultSavePaths(savePath string) (string, []string, error) {
+	contentPath := savePath + "/" + r.Name
+	var filePaths []string
+	if len(r.FilePaths) == 0 {
+		for i := range r.ImageURLs {
+			filePaths = append(filePaths, fmt.Sprintf("%s/%03d.jpg", contentPath, r.PageNumber(i)))
+		}
+	} else {
+		for _, p := range r.FilePaths {
+			filePaths = append(filePaths, fmt.Sprintf("%s/%s", contentPath, filepath.Base(p)))
+		}
+	}
+	return contentPath, filePaths, nil
+}
+
+// namingGallery 由解析结果与元数据生成画廊级模板变量
+func (r *ParseResult) namingGallery(siteType string) naming.Gallery {
+	gallery := naming.Gallery{
+		Site:  siteType,
+		Name:  r.Name,
+		Pages: len(r.ImageURLs),
+		Date:  time.Now(),
+	}
+	if meta := r.Metadata; meta != nil {
+		gallery.URL = meta.SourceURL
+		gallery.Title = meta.Title
+		gallery.OriginalTitle = meta.OriginalTitle
+		gallery.Artists = meta.Artists
+		gallery.Groups = meta.Groups
+		gallery.Parodies = meta.Parodies
+		gallery.Language = meta.Language
+		gallery.Category = meta.Category
+		gallery.UploadDate = meta.UploadDate
+		if meta.PageCount > 0 {
+			gallery.Pages = meta.PageCount
+		}
+	}
+	return gallery
+}
+
+// namingPage 第 i 张图片的模板变量，文件名优先取解析器提供的名称，其次取图片地址
+func (r *ParseResult) namingPage(i int) naming.Page {
+	name := ""
+	if i < len(r.FilePaths) {
+		name = filepath.Base(r.FilePaths[i])
+	} else if u, err := url.Parse(r.ImageURLs[i]); err == nil {
+		name = path.Base(u.Path)
+	}
+	ext := strings.ToLower(path.Ext(name))
+	switch ext {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp", ".avif":
+	default:
+		ext = defaultImageExt
+	}
+	return naming.Page{
+		Index: r.PageNumber(i),
+		Name:  strings.TrimSuffix(name, path.Ext(name)),
+		Ext:   ext,
+	}
+}
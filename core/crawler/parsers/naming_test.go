@@ -0,0 +1,44 @@
+package parsers
+
+import (
+	"reflect"
+	"testing"
+
+	"ImageMaster/core/types"
+)
+
+func TestParseResultSavePaths(t *testing.T) {
+	result := &ParseResult{
+		Name:        "Gallery",
+		ImageURLs:   []string{"https://img.test/a/p1.webp", "https://img.test/a/p3.php?id=3"},
+		PageNumbers: []int{1, 3},
+		Metadata: &GalleryMetadata{
+			Title:     "Title",
+			Artists:   []string{"Artist"},
+			SourceURL: "https://e-hentai.org/g/42/token/",
+		},
+	}
+
+	// 未配置模板时保持原有路径
+	dir, files, err := result.SavePaths("out", "ehentai", types.NamingTemplates{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != "out/Gallery" || !reflect.DeepEqual(files, []string{"out/Gallery/001.jpg", "out/Gallery/003.jpg"}) {
+		t.Errorf("default paths = %q %q", dir, files)
+	}
+
+	templates := types.NamingTemplates{Folder: "{site}/{artist}/[{id}] {title}", File: "{index:04}{ext}"}
+	dir, files, err = result.SavePaths("out", "ehentai", templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"out/ehentai/Artist/[42] Title/0001.webp", "out/ehentai/Artist/[42] Title/0003.jpg"}
+	if dir != "out/ehentai/Artist/[42] Title" || !reflect.DeepEqual(files, want) {
+		t.Errorf("template paths = %q %q", dir, files)
+	}
+
+	if _, _, err := result.SavePaths("out", "ehentai", types.NamingTemplates{Folder: "{nope}"}); err == nil {
+		t.Error("expected template error")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 
@@ -26,9 +27,10 @@ const (
 
 // NhentaiGallery Nhentai画廊
 type NhentaiGallery struct {
-	ID     string
-	Name   string
-	Images []string // 存储所有图片的URL
+	ID       string
+	Name     string
+	Images   []string // 存储所有图片的URL
+	Metadata *GalleryMetadata
 }
 
 // NhentaiParser Nhentai解析器实现
@@ -57,6 +59,7 @@ func (p *NhentaiParser) Parse(reqClient *request.Client, url string) (*ParseResu
 		Name:      nhentaiGallery.Name,
 		ImageURLs: nhentaiGallery.Images,
 		FilePaths: filePaths,
+		Metadata:  nhentaiGallery.Metadata,
 	}, nil
 }
 
@@ -125,13 +128,55 @@ func GetNhentaiGalleryWithClient(reqClient *request.Client, galleryURL string) (
 		fmt.Printf("通过API获取到额外 %d 张图片URL\n", len(moreImages))
 	}
 
+	metadata := parseNhentaiMetadata(doc)
+	metadata.Title = galleryName
+	metadata.SourceURL = galleryURL
+	metadata.Extra = map[string]string{"galleryId": galleryID}
+
 	return &NhentaiGallery{
-		ID:     galleryID,
-		Name:   galleryName,
-		Images: imageURLs,
+		ID:       galleryID,
+		Name:     galleryName,
+		Images:   imageURLs,
+		Metadata: metadata,
 	}, nil
 }
 
+// parseNhentaiMetadata 解析画廊信息区的标签
+// 每组标签以 "Artists:"、"Tags:" 等标题开头，标题即命名空间
+func parseNhentaiMetadata(doc *goquery.Document) *GalleryMetadata {
+	info := doc.Find(".gallery_top .info, #info").First()
+	metadata := &GalleryMetadata{
+		OriginalTitle: strings.TrimSpace(info.Find("h2, .subtitle").First().Text()),
+	}
+
+	info.Find(".tag-container, li.tags").Each(func(i int, s *goquery.Selection) {
+		label := strings.TrimSpace(s.Find(".tags_text").First().Text())
+		if label == "" {
+			label = strings.TrimSpace(s.Contents().First().Text())
+		}
+		namespace := strings.ToLower(strings.TrimSuffix(label, ":"))
+
+		names := s.Find(".name, .tag_name")
+		switch namespace {
+		case "pages":
+			metadata.PageCount, _ = strconv.Atoi(strings.TrimSpace(names.First().Text()))
+		case "uploaded":
+			if datetime, ok := s.Find("time[datetime]").Attr("datetime"); ok {
+				if t, err := time.Parse(time.RFC3339, datetime); err == nil {
+					metadata.UploadDate = t
+				}
+			}
+		default:
+			names.Each(func(j int, n *goquery.Selection) {
+				metadata.addNamespacedTag(namespace, n.Text())
+			})
+		}
+	})
+
+	metadata.Manga = mangaForCategory(metadata.Category)
+	return metadata
+}
+
 // extractGalleryID 从URL中提取画廊ID
 func extractGalleryID(galleryURL string) (string, error) {
 	// 从类似 "https://nhentai.xxx/g/537651/" 的URL中提取 "537651"
@@ -239,12 +284,13 @@ func getMoreImagesFromAPI(reqClient *request.Client, doc *goquery.Document, gall
 	formData.Set("total_pages", totalPagesStr)
 	formData.Set("type", "2")
 
-	// 设置请求头
-	reqClient.SetHeader("Content-Type", "application/x-www-form-urlencoded")
-	reqClient.SetHeader("X-Requested-With", "XMLHttpRequest")
-
-	// 发送POST请求
-	resp, err := reqClient.Post("https://nhentai.xxx/modules/thumbs_loader.php", strings.NewReader(formData.Encode()), "application/x-www-form-urlencoded")
+	// 发送POST请求，请求头只作用于本次请求
+	resp, err := reqClient.Do("POST", "https://nhentai.xxx/modules/thumbs_loader.php", strings.NewReader(formData.Encode()), request.Options{
+		Headers: map[string]string{
+			"Content-Type":     "application/x-www-form-urlencoded",
+			"X-Requested-With": "XMLHttpRequest",
+		},
+	})
 	if err != nil {
 		return nil, fmt.Errorf("API请求失败: %w", err)
 	}
@@ -293,4 +339,5 @@ func init() {
 		return NewNhentaiCrawler(reqClient)
 	})
 	RegisterHostContains(SiteTypeNhentai, "nhentai.xxx")
+	request.RegisterRateLimit(SiteTypeNhentai, types.RateLimitPolicy{RequestsPerSecond: 2, Burst: 4})
 }
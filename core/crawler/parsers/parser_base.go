@@ -8,6 +8,7 @@ import (
 	"ImageMaster/core/logger"
 	"ImageMaster/core/request"
 	"ImageMaster/core/types"
+	"ImageMaster/core/utils"
 )
 
 // ParseResult 解析结果
@@ -15,6 +16,19 @@ type ParseResult struct {
 	Name      string
 	ImageURLs []string
 	FilePaths []string
+	Headers   map[string]string // 下载图片时附加的请求头（可选）
+	Metadata  *GalleryMetadata  // 画廊元数据（可选，缺省由 BaseCrawler 补全）
+	// PageNumbers 每张图片在画廊中的原始页码（从 1 开始，可选）
+	// 解析器按页码选择跳过部分页面时需要填写，为空时按顺序编号
+	PageNumbers []int
+}
+
+// PageNumber 获取第 i 张图片的原始页码
+func (r *ParseResult) PageNumber(i int) int {
+	if i < len(r.PageNumbers) {
+		return r.PageNumbers[i]
+	}
+	return i + 1
 }
 
 // Parser 解析器接口
@@ -25,12 +39,27 @@ type Parser interface {
 	GetName() string
 }
 
+// ParsedCrawler 支持拆分解析与下载两个阶段的爬虫
+// 所有基于 BaseCrawler 的爬虫都实现了该接口
+type ParsedCrawler interface {
+	types.ImageCrawler
+	// Parse 仅执行解析
+	Parse(url string) (*ParseResult, error)
+	// CrawlParsed 下载已解析的结果
+	CrawlParsed(result *ParseResult, savePath string) error
+}
+
 // BaseCrawler 基础爬虫结构
 type BaseCrawler struct {
 	reqClient  *request.Client
 	downloader types.Downloader
 	parser     Parser
 	ctx        context.Context
+	pages      *utils.PageSelection  // 页码选择，nil 表示全部
+	siteType   string                // 站点类型，用于命名模板
+	naming     types.NamingTemplates // 保存路径命名模板，为空时使用默认规则
+	collision  types.CollisionPolicy // 画廊目录已存在时的处理方式
+	gallery    string                // 上次运行已决定的画廊目录，继续执行时沿用
 }
 
 // NewBaseCrawler 创建基础爬虫
@@ -70,6 +99,27 @@ func (c *BaseCrawler) SetContext(ctx context.Context) {
 	}
 }
 
+// SetPageSelection 设置页码选择，并传递给支持跳过页面的解析器
+func (c *BaseCrawler) SetPageSelection(pages *utils.PageSelection) {
+	c.pages = pages
+	if withPages, ok := c.parser.(interface{ SetPageSelection(*utils.PageSelection) }); ok {
+		withPages.SetPageSelection(pages)
+	}
+}
+
+// SetNaming 设置站点类型与保存路径命名模板
+func (c *BaseCrawler) SetNaming(siteType string, templates types.NamingTemplates) {
+	c.siteType = siteType
+	c.naming = templates
+}
+
+// SetCollision 设置画廊目录已存在时的处理方式
+// galleryPath 为任务上次运行时决定的目录，不为空时直接沿用并只补全缺失的图片
+func (c *BaseCrawler) SetCollision(policy types.CollisionPolicy, galleryPath string) {
+	c.collision = policy
+	c.gallery = galleryPath
+}
+
 // Crawl 执行爬取
 func (c *BaseCrawler) Crawl(url string, savePath string) (string, error) {
 	err := c.CrawlWithParser(url, savePath)
@@ -97,27 +147,54 @@ func (c *BaseCrawler) CrawlAndSave(url string, savePath string) string {
 
 // CrawlWithParser 使用解析器执行爬取
 func (c *BaseCrawler) CrawlWithParser(url string, savePath string) error {
-	logger.Info("下载 %s 内容: %s", c.parser.GetName(), url)
+	result, err := c.Parse(url)
+	if err != nil {
+		return err
+	}
+	return c.CrawlParsed(result, savePath)
+}
+
+// Parse 仅执行解析，不下载
+func (c *BaseCrawler) Parse(url string) (*ParseResult, error) {
+	logger.Info("解析 %s 内容: %s", c.parser.GetName(), url)
 
 	// 设置请求客户端
 	err := SetupRequestClient(c.reqClient, c.downloader)
 	if err != nil {
-		return fmt.Errorf("设置请求客户端失败: %w", err)
+		return nil, fmt.Errorf("设置请求客户端失败: %w", err)
 	}
 
 	// 解析前取消检查
 	if c.ctx != nil {
 		if err := c.ctx.Err(); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
 	// 解析内容
 	result, err := c.parser.Parse(c.reqClient, url)
 	if err != nil {
-		return fmt.Errorf("解析内容失败: %w", err)
+		return nil, fmt.Errorf("解析内容失败: %w", err)
+	}
+
+	// 补全元数据的通用字段
+	if result.Metadata == nil {
+		result.Metadata = &GalleryMetadata{}
+	}
+	if result.Metadata.Title == "" {
+		result.Metadata.Title = result.Name
+	}
+	if result.Metadata.PageCount == 0 {
+		result.Metadata.PageCount = len(result.ImageURLs)
 	}
+	if result.Metadata.SourceURL == "" {
+		result.Metadata.SourceURL = url
+	}
+	return result, nil
+}
 
+// CrawlParsed 下载已解析的结果，可用于跳过重复解析
+func (c *BaseCrawler) CrawlParsed(result *ParseResult, savePath string) error {
 	// 解析后快速取消检查
 	if c.ctx != nil {
 		if err := c.ctx.Err(); err != nil {
@@ -135,25 +212,49 @@ func (c *BaseCrawler) CrawlWithParser(url string, savePath string) error {
 	}
 	logger.Debug("%s解析器使用传入的下载器", c.parser.GetName())
 
-	// 准备下载路径
-	contentPath := savePath + "/" + result.Name
-
-	// 准备文件路径
-	filePaths := result.FilePaths
-	if len(filePaths) == 0 {
-		// 如果解析器没有提供文件路径，生成默认路径
-		for i := range result.ImageURLs {
-			filename := fmt.Sprintf("%03d.jpg", i+1)
-			fullPath := fmt.Sprintf("%s/%s", contentPath, filename)
-			filePaths = append(filePaths, fullPath)
+	// 按命名模板准备下载路径（不修改 result，便于重复使用）
+	contentPath, filePaths, err := result.SavePaths(savePath, c.siteType, c.naming)
+	if err != nil {
+		return err
+	}
+
+	// 按处理方式决定已存在的画廊目录，继续执行的任务沿用上次的目录
+	action, galleryPath := types.CollisionPolicy(""), c.gallery
+	if galleryPath == "" {
+		if action, galleryPath, err = ResolveCollision(c.collision, contentPath); err != nil {
+			return err
+		}
+		RecordCollision(c.downloader, action, galleryPath)
+	}
+	filePaths = rebasePaths(filePaths, contentPath, galleryPath)
+	contentPath = galleryPath
+	if action != "" {
+		logger.Info("画廊目录已存在，处理方式: %s，目录: %s", action, contentPath)
+	}
+	if action == types.CollisionSkip {
+		UpdateTaskStatus(c.downloader, types.StatusSkipped, "画廊目录已存在，已跳过")
+		return nil
+	}
+
+	// 按页码选择过滤，文件名保留原始页码
+	imageURLs, filePaths, pageNumbers := SelectPages(result, filePaths, c.pages)
+	if len(imageURLs) == 0 {
+		return fmt.Errorf("所选页码 %s 中没有图片", c.pages)
+	}
+	if action == types.CollisionOverwrite {
+		if err := removeImages(filePaths); err != nil {
+			return err
 		}
-	} else {
-		// 更新文件路径前缀
-		for i, path := range filePaths {
-			filePaths[i] = fmt.Sprintf("%s/%s", contentPath, filepath.Base(path))
+	}
+	SetTaskItems(c.downloader, imageURLs, filePaths, pageNumbers)
+
+	// 写入 ComicInfo.xml 与 info.json，失败不影响下载
+	if result.Metadata != nil {
+		if err := WriteGalleryMetadata(contentPath, result.Metadata, len(imageURLs)); err != nil {
+			logger.Warn("写入画廊元数据失败: %v", err)
 		}
 	}
 
 	// 执行批量下载
-	return BatchDownloadWithProgress(c.downloader, result.ImageURLs, filePaths)
+	return BatchDownloadWithProgress(c.downloader, imageURLs, filePaths, result.Headers)
 }
@@ -6,6 +6,7 @@ import (
 	"ImageMaster/core/logger"
 	"ImageMaster/core/request"
 	"ImageMaster/core/types"
+	"ImageMaster/core/utils"
 )
 
 // SetupRequestClient 设置请求客户端的通用配置
@@ -46,8 +47,58 @@ func UpdateTaskProgress(downloader types.Downloader, current, total int) {
 	}
 }
 
+// RecordCollision 在任务上记录画廊目录冲突的处理结果与实际使用的目录
+func RecordCollision(downloader types.Downloader, action types.CollisionPolicy, galleryPath string) {
+	if downloader != nil {
+		if taskUpdater := downloader.GetTaskUpdater(); taskUpdater != nil {
+			taskUpdater.UpdateTaskField("collision", string(action))
+			taskUpdater.UpdateTaskField("galleryPath", galleryPath)
+			logger.Debug("已记录画廊目录: %s，冲突处理: %s", galleryPath, action)
+		}
+	}
+}
+
+// SelectPages 按页码选择过滤图片地址和对应的文件路径，同时返回各图片的原始页码
+func SelectPages(result *ParseResult, filePaths []string, pages *utils.PageSelection) ([]string, []string, []int) {
+	var selectedURLs, selectedPaths []string
+	var pageNumbers []int
+	for i, imgURL := range result.ImageURLs {
+		if pages.Contains(result.PageNumber(i)) {
+			selectedURLs = append(selectedURLs, imgURL)
+			selectedPaths = append(selectedPaths, filePaths[i])
+			pageNumbers = append(pageNumbers, result.PageNumber(i))
+		}
+	}
+	if pages != nil {
+		logger.Info("按页码 %s 选择了 %d/%d 张图片", pages, len(selectedURLs), len(result.ImageURLs))
+	}
+	return selectedURLs, selectedPaths, pageNumbers
+}
+
+// SetTaskItems 登记本次要下载的全部图片，便于逐张跟踪与重试
+func SetTaskItems(downloader types.Downloader, imageURLs, filePaths []string, pageNumbers []int) {
+	if downloader == nil {
+		return
+	}
+	taskUpdater := downloader.GetTaskUpdater()
+	if taskUpdater == nil {
+		return
+	}
+	items := make([]types.DownloadItem, len(imageURLs))
+	for i := range imageURLs {
+		items[i] = types.DownloadItem{
+			Index:      i,
+			PageNumber: pageNumbers[i],
+			URL:        imageURLs[i],
+			FilePath:   filePaths[i],
+			Status:     string(types.StatusPending),
+		}
+	}
+	taskUpdater.SetItems(items)
+}
+
 // BatchDownloadWithProgress 带进度的批量下载
-func BatchDownloadWithProgress(downloader types.Downloader, imageURLs, filePaths []string) error {
+func BatchDownloadWithProgress(downloader types.Downloader, imageURLs, filePaths []string, headers map[string]string) error {
 	totalImages := len(imageURLs)
 	logger.Info("已收集 %d 张图片URL，开始下载...", totalImages)
 
@@ -56,7 +107,9 @@ func BatchDownloadWithProgress(downloader types.Downloader, imageURLs, filePaths
 	UpdateTaskProgress(downloader, 0, totalImages)
 
 	// 批量下载所有图片
-	headers := make(map[string]string)
+	if headers == nil {
+		headers = make(map[string]string)
+	}
 	successImages, err := downloader.BatchDownload(imageURLs, filePaths, headers)
 	if err != nil {
 		logger.Error("批量下载出错: %v", err)
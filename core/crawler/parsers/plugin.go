@@ -0,0 +1,345 @@
+package parsers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ImageMaster/core/logger"
+	"ImageMaster/core/naming"
+	"ImageMaster/core/request"
+	"ImageMaster/core/types"
+)
+
+// SiteTypePluginPrefix 外部插件注册的站点类型前缀，如 plugin:example
+const SiteTypePluginPrefix = "plugin:"
+
+const (
+	DefaultPluginTimeout = 10 * time.Minute // 插件默认超时
+	pluginMaxOutput      = 32 << 20         // 插件标准输出上限
+	pluginStderrTail     = 2048             // 错误信息中保留的 stderr 尾部长度
+	pluginWaitDelay      = 3 * time.Second  // 进程被终止后等待管道关闭的时间
+)
+
+// PluginManifest 外部插件清单（放置于插件目录中的 <name>.json）
+type PluginManifest struct {
+	Name    string   `json:"name"`    // 插件名称
+	Command []string `json:"command"` // 可执行文件及参数，如 ["python3", "scraper.py"]
+	Hosts   []string `json:"hosts"`   // host 匹配模式，规则同站点规则
+	Timeout int      `json:"timeout"` // 超时（秒），0 使用默认值
+
+	dir string // 插件目录，作为进程工作目录
+}
+
+// PluginCookie 传递给插件的 Cookie
+type PluginCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PluginRequest 写入插件标准输入的请求
+type PluginRequest struct {
+	URL     string         `json:"url"`
+	Cookies []PluginCookie `json:"cookies"`
+	Proxy   string         `json:"proxy"`
+}
+
+// PluginResponse 插件写入标准输出的结果，与 ParseResult 字段对应
+type PluginResponse struct {
+	Name      string            `json:"name"`
+	ImageURLs []string          `json:"imageUrls"`
+	FilePaths []string          `json:"filePaths"`
+	Headers   map[string]string `json:"headers"`
+	Metadata  *GalleryMetadata  `json:"metadata"`
+	Error     string            `json:"error"` // 插件主动报告的错误
+}
+
+// SiteType 获取插件对应的站点类型
+func (m *PluginManifest) SiteType() string {
+	return SiteTypePluginPrefix + m.Name
+}
+
+// Validate 校验插件清单
+func (m *PluginManifest) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("缺少字段 name")
+	}
+	if !extensionNamePattern.MatchString(m.Name) {
+		return fmt.Errorf("字段 name 只能包含字母、数字、下划线和中划线: %q", m.Name)
+	}
+	if len(m.Command) == 0 || strings.TrimSpace(m.Command[0]) == "" {
+		return fmt.Errorf("缺少字段 command")
+	}
+	if m.Timeout < 0 {
+		return fmt.Errorf("字段 timeout 不能为负数")
+	}
+	return validateHostPatterns(m.Hosts)
+}
+
+// MatchHost 判断 host 是否由插件处理
+func (m *PluginManifest) MatchHost(host string) bool {
+	return matchHostPatterns(m.Hosts, host)
+}
+
+func (m *PluginManifest) timeout() time.Duration {
+	if m.Timeout > 0 {
+		return time.Duration(m.Timeout) * time.Second
+	}
+	return DefaultPluginTimeout
+}
+
+// LoadPluginManifest 从文件加载并校验插件清单
+func LoadPluginManifest(file string) (*PluginManifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("插件清单 %s: 读取失败: %w", file, err)
+	}
+
+	var manifest PluginManifest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("插件清单 %s: JSON 格式错误: %w", file, err)
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, fmt.Errorf("插件清单 %s: %w", file, err)
+	}
+	manifest.dir = filepath.Dir(file)
+	return &manifest, nil
+}
+
+// Run 启动插件进程并解析其输出
+// 进程崩溃、超时、输出格式错误均以错误返回，任务取消时终止进程
+func (m *PluginManifest) Run(ctx context.Context, req PluginRequest) (*ParseResult, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, m.timeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, m.Command[0], m.Command[1:]...)
+	cmd.Dir = m.dir
+	cmd.WaitDelay = pluginWaitDelay
+	cmd.Stdin = bytes.NewReader(payload)
+	stdout := &cappedBuffer{limit: pluginMaxOutput}
+	stderr := &cappedBuffer{limit: pluginMaxOutput, keepTail: true}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	logger.Info("运行插件 %s: %s", m.Name, strings.Join(m.Command, " "))
+	runErr := cmd.Run()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("插件 %s 执行超时 (%v)", m.Name, m.timeout())
+	}
+	if runErr != nil {
+		return nil, fmt.Errorf("插件 %s 异常退出: %v%s", m.Name, runErr, stderr.tail())
+	}
+	if stdout.overflow {
+		return nil, fmt.Errorf("插件 %s 输出超过 %d 字节", m.Name, pluginMaxOutput)
+	}
+
+	var resp PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("插件 %s 输出格式错误: %v%s", m.Name, err, stderr.tail())
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("插件 %s 返回错误: %s", m.Name, resp.Error)
+	}
+	if len(resp.ImageURLs) == 0 {
+		return nil, fmt.Errorf("插件 %s 未返回任何图片", m.Name)
+	}
+	if len(resp.FilePaths) != 0 && len(resp.FilePaths) != len(resp.ImageURLs) {
+		return nil, fmt.Errorf("插件 %s 返回的 filePaths 与 imageUrls 数量不一致", m.Name)
+	}
+	name, filePaths, err := cleanExternalNames(resp.Name, m.Name, resp.FilePaths)
+	if err != nil {
+		return nil, fmt.Errorf("插件 %s %w", m.Name, err)
+	}
+
+	return &ParseResult{
+		Name:      name,
+		ImageURLs: resp.ImageURLs,
+		FilePaths: filePaths,
+		Headers:   resp.Headers,
+		Metadata:  resp.Metadata,
+	}, nil
+}
+
+// cleanExternalNames 规范化插件与脚本返回的画廊名与文件名，避免 ..、\ 等写到下载目录之外
+// 画廊名清理后为空时使用 fallback
+func cleanExternalNames(name, fallback string, filePaths []string) (string, []string, error) {
+	name = naming.CleanName(name, false)
+	if name == "" {
+		name = naming.CleanName(fallback, false)
+	}
+	cleaned := make([]string, len(filePaths))
+	for i, p := range filePaths {
+		// 只取文件名部分，与默认保存规则一致
+		p = path.Base(strings.ReplaceAll(p, "\\", "/"))
+		if cleaned[i] = naming.CleanName(p, true); cleaned[i] == "" {
+			return "", nil, fmt.Errorf("返回的文件名无效: %q", filePaths[i])
+		}
+	}
+	return name, cleaned, nil
+}
+
+// cappedBuffer 限制大小的输出缓冲区，超出部分丢弃
+type cappedBuffer struct {
+	bytes.Buffer
+	limit    int
+	keepTail bool // 为 true 时只保留最后 pluginStderrTail 字节
+	overflow bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if b.keepTail {
+		b.Buffer.Write(p)
+		if b.Len() > pluginStderrTail {
+			tail := append([]byte(nil), b.Bytes()[b.Len()-pluginStderrTail:]...)
+			b.Reset()
+			b.Buffer.Write(tail)
+		}
+		return n, nil
+	}
+	if remaining := b.limit - b.Len(); remaining < len(p) {
+		b.overflow = true
+		if remaining > 0 {
+			b.Buffer.Write(p[:remaining])
+		}
+		return n, nil
+	}
+	b.Buffer.Write(p)
+	return n, nil
+}
+
+// tail 返回用于拼接错误信息的 stderr 尾部
+func (b *cappedBuffer) tail() string {
+	text := strings.TrimSpace(b.String())
+	if text == "" {
+		return ""
+	}
+	return "\nstderr: " + text
+}
+
+var (
+	pluginsMu         sync.Mutex
+	loadedPluginTypes []string // 当前已注册的插件站点类型
+)
+
+// ReloadPlugins 重新加载插件目录并替换之前注册的全部插件
+// 返回成功加载的插件名称；清单有问题的插件不会注册，错误一并返回
+func ReloadPlugins(dir string) ([]string, error) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var manifests []*PluginManifest
+	var errs []error
+	seen := make(map[string]string)
+	for _, file := range files {
+		manifest, err := LoadPluginManifest(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if prev, exists := seen[manifest.Name]; exists {
+			errs = append(errs, fmt.Errorf("插件清单 %s: 名称 %q 与 %s 重复", file, manifest.Name, prev))
+			continue
+		}
+		seen[manifest.Name] = file
+		manifests = append(manifests, manifest)
+	}
+
+	for _, siteType := range loadedPluginTypes {
+		Unregister(siteType)
+		UnregisterHostMatchers(siteType)
+	}
+	loadedPluginTypes = nil
+
+	var names []string
+	for _, manifest := range manifests {
+		Register(manifest.SiteType(), func(reqClient *request.Client, cfg types.ConfigProvider) types.ImageCrawler {
+			return NewPluginCrawler(reqClient, manifest)
+		})
+		RegisterHostMatcher(manifest.SiteType(), manifest.MatchHost)
+		loadedPluginTypes = append(loadedPluginTypes, manifest.SiteType())
+		names = append(names, manifest.Name)
+		logger.Info("已加载外部插件 %s", manifest.Name)
+	}
+
+	loadErr := errors.Join(errs...)
+	if loadErr != nil {
+		logger.Warn("部分外部插件加载失败: %v", loadErr)
+	}
+	return names, loadErr
+}
+
+// PluginParser 调用外部插件的解析器
+type PluginParser struct {
+	manifest *PluginManifest
+	ctx      context.Context
+}
+
+// SetContext 注入上下文，任务取消时终止插件进程
+func (p *PluginParser) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
+// GetName 获取解析器名称
+func (p *PluginParser) GetName() string {
+	return "Plugin(" + p.manifest.Name + ")"
+}
+
+// Parse 解析URL获取图片信息
+func (p *PluginParser) Parse(reqClient *request.Client, url string) (*ParseResult, error) {
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	req := PluginRequest{
+		URL:     url,
+		Cookies: []PluginCookie{},
+		Proxy:   reqClient.GetProxy(),
+	}
+	for _, cookie := range reqClient.Cookies() {
+		req.Cookies = append(req.Cookies, PluginCookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	return p.manifest.Run(ctx, req)
+}
+
+// PluginCrawler 基于外部插件的爬虫
+type PluginCrawler struct {
+	*BaseCrawler
+}
+
+// NewPluginCrawler 创建插件爬虫
+func NewPluginCrawler(reqClient *request.Client, manifest *PluginManifest) types.ImageCrawler {
+	parser := &PluginParser{manifest: manifest}
+	baseCrawler := NewBaseCrawler(reqClient, parser)
+	return &PluginCrawler{
+		BaseCrawler: baseCrawler,
+	}
+}
@@ -0,0 +1,104 @@
+package parsers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPluginHelperProcess 作为插件子进程运行，由其它测试通过 os.Args[0] 启动
+func TestPluginHelperProcess(t *testing.T) {
+	if os.Getenv("IMAGEMASTER_PLUGIN_HELPER") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	var req PluginRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, "bad request:", err)
+		os.Exit(2)
+	}
+
+	switch os.Args[len(os.Args)-1] {
+	case "ok":
+		json.NewEncoder(os.Stdout).Encode(PluginResponse{
+			Name:      "Plugin Gallery",
+			ImageURLs: []string{req.URL + "/1.jpg", req.URL + "/2.jpg"},
+			Headers:   map[string]string{"Referer": req.URL},
+			Metadata:  &GalleryMetadata{Extra: map[string]string{"proxy": req.Proxy, "cookie": req.Cookies[0].Value}},
+		})
+	case "crash":
+		fmt.Fprintln(os.Stderr, "boom: site layout changed")
+		os.Exit(3)
+	case "garbage":
+		fmt.Fprint(os.Stdout, "<html>not json</html>")
+	case "hang":
+		time.Sleep(time.Minute)
+	}
+}
+
+func helperManifest(mode string, timeout int) *PluginManifest {
+	return &PluginManifest{
+		Name:    "helper",
+		Command: []string{os.Args[0], "-test.run=TestPluginHelperProcess", "--", mode},
+		Hosts:   []string{"example.com"},
+		Timeout: timeout,
+	}
+}
+
+func TestPluginManifestRun(t *testing.T) {
+	t.Setenv("IMAGEMASTER_PLUGIN_HELPER", "1")
+	req := PluginRequest{URL: "https://example.com/g", Proxy: "http://127.0.0.1:7890", Cookies: []PluginCookie{{Name: "nw", Value: "1"}}}
+
+	result, err := helperManifest("ok", 0).Run(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Run(ok) error = %v", err)
+	}
+	if result.Name != "Plugin Gallery" || len(result.ImageURLs) != 2 || result.Headers["Referer"] != req.URL {
+		t.Errorf("Run(ok) = %+v", result)
+	}
+	if result.Metadata.Extra["proxy"] != req.Proxy || result.Metadata.Extra["cookie"] != "1" {
+		t.Errorf("plugin did not receive request fields: %+v", result.Metadata)
+	}
+
+	if _, err := helperManifest("crash", 0).Run(context.Background(), req); err == nil || !strings.Contains(err.Error(), "site layout changed") {
+		t.Errorf("Run(crash) error = %v, expected stderr in message", err)
+	}
+	if _, err := helperManifest("garbage", 0).Run(context.Background(), req); err == nil || !strings.Contains(err.Error(), "输出格式错误") {
+		t.Errorf("Run(garbage) error = %v, expected malformed output error", err)
+	}
+
+	start := time.Now()
+	if _, err := helperManifest("hang", 1).Run(context.Background(), req); err == nil || !strings.Contains(err.Error(), "超时") {
+		t.Errorf("Run(hang) error = %v, expected timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Run(hang) took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	if _, err := helperManifest("hang", 0).Run(ctx, req); err != context.Canceled {
+		t.Errorf("Run(hang) with cancelled context error = %v, expected context.Canceled", err)
+	}
+}
+
+func TestCleanExternalNames(t *testing.T) {
+	name, files, err := cleanExternalNames("..", "demo", []string{`..\..\001.jpg`, "sub/002.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "demo" || strings.Join(files, "|") != "001.jpg|002.png" {
+		t.Errorf("cleanExternalNames = %q %v", name, files)
+	}
+	if name, _, _ := cleanExternalNames(`a\..\b`, "demo", nil); name != "a_.._b" {
+		t.Errorf("name = %q", name)
+	}
+	if _, _, err := cleanExternalNames("demo", "demo", []string{"a/.."}); err == nil {
+		t.Error("expected error for invalid file name")
+	}
+}
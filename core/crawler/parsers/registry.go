@@ -1,6 +1,7 @@
 package parsers
 
 import (
+	"net/url"
 	"strings"
 	"sync"
 
@@ -32,6 +33,11 @@ const (
 	SiteTypeGeneric   = "generic"
 )
 
+// 请求限速按 host 识别站点类型
+func init() {
+	request.SetSiteResolver(DetectSiteTypeByHost)
+}
+
 // Register 在注册表中注册站点爬虫构造器
 func Register(siteType string, ctor CrawlerConstructor) {
 	registryMu.Lock()
@@ -39,6 +45,13 @@ func Register(siteType string, ctor CrawlerConstructor) {
 	crawlerRegistry[siteType] = ctor
 }
 
+// Unregister 从注册表中移除站点爬虫构造器
+func Unregister(siteType string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(crawlerRegistry, siteType)
+}
+
 // CreateCrawler 根据站点类型创建爬虫实例
 func CreateCrawler(siteType string, reqClient *request.Client, cfg types.ConfigProvider) types.ImageCrawler {
 	registryMu.RLock()
@@ -50,14 +63,18 @@ func CreateCrawler(siteType string, reqClient *request.Client, cfg types.ConfigP
 	return ctor(reqClient, cfg)
 }
 
-// ---- Host 匹配注册与检测 ----
+// ---- Host/URL 匹配注册与检测 ----
 
 // HostMatcher 用于匹配 host 是否属于某站点
 type HostMatcher func(host string) bool
 
+// URLMatcher 用于匹配完整 URL 是否属于某站点（如脚本解析器的 match 函数）
+type URLMatcher func(rawURL string) bool
+
 type hostMatcherEntry struct {
-	siteType string
-	matcher  HostMatcher
+	siteType   string
+	matcher    HostMatcher
+	urlMatcher URLMatcher
 }
 
 // RegisterHostMatcher 注册一个自定义 Host 匹配器
@@ -67,6 +84,26 @@ func RegisterHostMatcher(siteType string, matcher HostMatcher) {
 	hostMatchers = append(hostMatchers, hostMatcherEntry{siteType: siteType, matcher: matcher})
 }
 
+// RegisterURLMatcher 注册一个基于完整 URL 的匹配器，与 Host 匹配器按注册顺序依次检测
+func RegisterURLMatcher(siteType string, matcher URLMatcher) {
+	hostRegistryMu.Lock()
+	defer hostRegistryMu.Unlock()
+	hostMatchers = append(hostMatchers, hostMatcherEntry{siteType: siteType, urlMatcher: matcher})
+}
+
+// UnregisterHostMatchers 移除某站点类型的全部 Host/URL 匹配器
+func UnregisterHostMatchers(siteType string) {
+	hostRegistryMu.Lock()
+	defer hostRegistryMu.Unlock()
+	kept := hostMatchers[:0]
+	for _, entry := range hostMatchers {
+		if entry.siteType != siteType {
+			kept = append(kept, entry)
+		}
+	}
+	hostMatchers = kept
+}
+
 // RegisterHostContains 以包含子串的方式注册 Host 规则
 func RegisterHostContains(siteType string, substrings ...string) {
 	RegisterHostMatcher(siteType, func(host string) bool {
@@ -79,7 +116,7 @@ func RegisterHostContains(siteType string, substrings ...string) {
 	})
 }
 
-// DetectSiteTypeByHost 根据 host 识别站点类型
+// DetectSiteTypeByHost 根据 host 识别站点类型（不检测 URL 匹配器）
 func DetectSiteTypeByHost(host string) string {
 	hostRegistryMu.RLock()
 	defer hostRegistryMu.RUnlock()
@@ -91,4 +128,29 @@ func DetectSiteTypeByHost(host string) string {
 	return SiteTypeGeneric
 }
 
+// DetectSiteType 根据完整 URL 识别站点类型
+func DetectSiteType(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return SiteTypeGeneric
+	}
+	host := parsedURL.Host
+
+	// 拷贝一份匹配器，避免在持锁期间执行耗时的 URL 匹配（如脚本）
+	hostRegistryMu.RLock()
+	entries := make([]hostMatcherEntry, len(hostMatchers))
+	copy(entries, hostMatchers)
+	hostRegistryMu.RUnlock()
+
+	for _, entry := range entries {
+		if entry.matcher != nil && entry.matcher(host) {
+			return entry.siteType
+		}
+		if entry.urlMatcher != nil && entry.urlMatcher(rawURL) {
+			return entry.siteType
+		}
+	}
+	return SiteTypeGeneric
+}
+
 // 末尾保留
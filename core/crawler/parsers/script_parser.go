@@ -0,0 +1,435 @@
+package parsers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/robertkrimen/otto"
+
+	"ImageMaster/core/logger"
+	"ImageMaster/core/request"
+	"ImageMaster/core/types"
+)
+
+// SiteTypeScriptPrefix 脚本解析器注册的站点类型前缀，如 script:example
+const SiteTypeScriptPrefix = "script:"
+
+// 脚本调用超时
+const (
+	DefaultScriptMatchTimeout = 2 * time.Second // match(url) 的超时
+	DefaultScriptParseTimeout = 5 * time.Minute // parse(url) 的默认超时，可由脚本中的 timeout 变量（秒）覆盖
+)
+
+// errScriptInterrupted 用于中断 otto 虚拟机执行的哨兵值
+type errScriptInterrupted struct {
+	cause error
+}
+
+// ParserScript 已加载的用户脚本
+// 脚本需定义 match(url) 与 parse(url) 两个函数，可使用宿主函数 fetch/select/log
+type ParserScript struct {
+	Name string
+	File string
+
+	source       string
+	parseTimeout time.Duration
+}
+
+// scriptParseResult parse(url) 的返回值结构
+type scriptParseResult struct {
+	Name     string            `json:"name"`
+	Images   []string          `json:"images"`
+	Files    []string          `json:"files"`
+	Headers  map[string]string `json:"headers"`
+	Metadata *GalleryMetadata  `json:"metadata"`
+}
+
+// SiteType 获取脚本对应的站点类型
+func (s *ParserScript) SiteType() string {
+	return SiteTypeScriptPrefix + s.Name
+}
+
+// LoadParserScript 加载并校验脚本文件
+func LoadParserScript(file string) (*ParserScript, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("脚本文件 %s: 读取失败: %w", file, err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	if !extensionNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("脚本文件 %s: 文件名只能包含字母、数字、下划线和中划线", file)
+	}
+
+	script := &ParserScript{
+		Name:         name,
+		File:         file,
+		source:       string(data),
+		parseTimeout: DefaultScriptParseTimeout,
+	}
+
+	// 在独立虚拟机中执行一次顶层代码，检查语法与导出函数
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultScriptMatchTimeout)
+	defer cancel()
+	vm, err := script.newVM(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = script.guard(ctx, vm, func() error {
+		if err := script.runSource(vm); err != nil {
+			return err
+		}
+		for _, fn := range []string{"match", "parse"} {
+			v, err := vm.Get(fn)
+			if err != nil || !v.IsFunction() {
+				return fmt.Errorf("未定义函数 %s(url)", fn)
+			}
+		}
+		if v, err := vm.Get("timeout"); err == nil && v.IsNumber() {
+			if seconds, err := v.ToFloat(); err == nil && seconds > 0 {
+				script.parseTimeout = time.Duration(seconds * float64(time.Second))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("脚本文件 %s: %w", file, err)
+	}
+	return script, nil
+}
+
+// Match 调用脚本的 match(url)，出错或超时视为不匹配
+func (s *ParserScript) Match(rawURL string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultScriptMatchTimeout)
+	defer cancel()
+	vm, err := s.newVM(ctx, nil)
+	if err != nil {
+		return false
+	}
+
+	matched := false
+	err = s.guard(ctx, vm, func() error {
+		if err := s.runSource(vm); err != nil {
+			return err
+		}
+		v, err := vm.Call("match", nil, rawURL)
+		if err != nil {
+			return err
+		}
+		matched, err = v.ToBoolean()
+		return err
+	})
+	if err != nil {
+		logger.Warn("脚本 %s 执行 match 失败: %v", s.Name, err)
+		return false
+	}
+	return matched
+}
+
+// Parse 调用脚本的 parse(url)，超时或上下文取消时中断执行
+func (s *ParserScript) Parse(ctx context.Context, reqClient *request.Client, rawURL string) (*ParseResult, error) {
+	var result scriptParseResult
+
+	callCtx, cancel := context.WithTimeout(ctx, s.parseTimeout)
+	defer cancel()
+
+	vm, err := s.newVM(callCtx, reqClient)
+	if err != nil {
+		return nil, err
+	}
+	err = s.guard(callCtx, vm, func() error {
+		if err := s.runSource(vm); err != nil {
+			return err
+		}
+		v, err := vm.Call("parse", nil, rawURL)
+		if err != nil {
+			return err
+		}
+		encoded, err := vm.Call("JSON.stringify", nil, v)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(encoded.String()), &result); err != nil {
+			return fmt.Errorf("parse 返回值格式错误: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("脚本 %s: %w", s.Name, err)
+	}
+
+	if len(result.Images) == 0 {
+		return nil, fmt.Errorf("脚本 %s 未返回任何图片", s.Name)
+	}
+	if len(result.Files) != 0 && len(result.Files) != len(result.Images) {
+		return nil, fmt.Errorf("脚本 %s 返回的 files 与 images 数量不一致", s.Name)
+	}
+	name, filePaths, err := cleanExternalNames(result.Name, s.Name, result.Files)
+	if err != nil {
+		return nil, fmt.Errorf("脚本 %s %w", s.Name, err)
+	}
+
+	return &ParseResult{
+		Name:      name,
+		ImageURLs: result.Images,
+		FilePaths: filePaths,
+		Headers:   result.Headers,
+		Metadata:  result.Metadata,
+	}, nil
+}
+
+// runSource 在虚拟机中执行脚本顶层代码
+func (s *ParserScript) runSource(vm *otto.Otto) error {
+	program, err := vm.Compile(s.File, s.source)
+	if err != nil {
+		return fmt.Errorf("语法错误: %w", err)
+	}
+	_, err = vm.Run(program)
+	return err
+}
+
+// guard 执行 fn，ctx 超时或取消时通过 Interrupt 中断虚拟机
+func (s *ParserScript) guard(ctx context.Context, vm *otto.Otto, fn func() error) (err error) {
+	vm.Interrupt = make(chan func(), 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			vm.Interrupt <- func() {
+				panic(errScriptInterrupted{cause: ctx.Err()})
+			}
+		case <-done:
+		}
+	}()
+
+	defer func() {
+		if r := recover(); r != nil {
+			if halt, ok := r.(errScriptInterrupted); ok {
+				if errors.Is(halt.cause, context.DeadlineExceeded) {
+					err = fmt.Errorf("执行超时")
+				} else {
+					err = halt.cause
+				}
+				return
+			}
+			err = fmt.Errorf("脚本执行异常: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// newVM 创建注入了宿主函数的虚拟机；reqClient 为 nil 时 fetch 不可用
+func (s *ParserScript) newVM(ctx context.Context, reqClient *request.Client) (*otto.Otto, error) {
+	vm := otto.New()
+
+	throw := func(format string, args ...interface{}) {
+		panic(vm.MakeCustomError("Error", fmt.Sprintf(format, args...)))
+	}
+
+	// fetch(url, {method, body, headers}) 返回响应文本，非 2xx 时抛出异常
+	fetch := func(call otto.FunctionCall) otto.Value {
+		if reqClient == nil {
+			throw("fetch 仅可在 parse 中使用")
+		}
+		target := call.Argument(0).String()
+		method := http.MethodGet
+		var body io.Reader
+		headers := map[string]string{}
+
+		if opts := call.Argument(1); opts.IsObject() {
+			obj := opts.Object()
+			if v, err := obj.Get("method"); err == nil && v.IsString() {
+				method = strings.ToUpper(v.String())
+			}
+			if v, err := obj.Get("body"); err == nil && v.IsString() {
+				body = strings.NewReader(v.String())
+			}
+			if v, err := obj.Get("headers"); err == nil && v.IsObject() {
+				h := v.Object()
+				for _, key := range h.Keys() {
+					if hv, err := h.Get(key); err == nil {
+						headers[key] = hv.String()
+					}
+				}
+			}
+		}
+
+		resp, err := reqClient.DoRequestWithContext(ctx, method, target, body, headers)
+		if err != nil {
+			throw("请求 %s 失败: %v", target, err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			throw("读取 %s 失败: %v", target, err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			throw("请求 %s 返回状态码 %d", target, resp.StatusCode)
+		}
+		v, _ := vm.ToValue(string(data))
+		return v
+	}
+
+	// select(html, css[, attr]) 指定 attr 时返回属性值数组，否则返回 {text, html, attrs} 数组
+	selectFn := func(call otto.FunctionCall) otto.Value {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(call.Argument(0).String()))
+		if err != nil {
+			throw("解析 HTML 失败: %v", err)
+		}
+		selector := call.Argument(1).String()
+		attr := ""
+		if a := call.Argument(2); a.IsString() {
+			attr = a.String()
+		}
+
+		var items []interface{}
+		doc.Find(selector).Each(func(i int, sel *goquery.Selection) {
+			if attr != "" {
+				if v, ok := sel.Attr(attr); ok {
+					items = append(items, v)
+				}
+				return
+			}
+			html, _ := goquery.OuterHtml(sel)
+			attrs := map[string]interface{}{}
+			if len(sel.Nodes) > 0 {
+				for _, a := range sel.Nodes[0].Attr {
+					attrs[a.Key] = a.Val
+				}
+			}
+			items = append(items, map[string]interface{}{
+				"text":  sel.Text(),
+				"html":  html,
+				"attrs": attrs,
+			})
+		})
+		if items == nil {
+			items = []interface{}{}
+		}
+		v, err := vm.ToValue(items)
+		if err != nil {
+			throw("转换结果失败: %v", err)
+		}
+		return v
+	}
+
+	// log(...) 输出到应用日志
+	logFn := func(call otto.FunctionCall) otto.Value {
+		parts := make([]string, 0, len(call.ArgumentList))
+		for _, arg := range call.ArgumentList {
+			parts = append(parts, arg.String())
+		}
+		logger.Info("[script:%s] %s", s.Name, strings.Join(parts, " "))
+		return otto.UndefinedValue()
+	}
+
+	for name, fn := range map[string]interface{}{"fetch": fetch, "select": selectFn, "log": logFn} {
+		if err := vm.Set(name, fn); err != nil {
+			return nil, fmt.Errorf("注入宿主函数 %s 失败: %w", name, err)
+		}
+	}
+	return vm, nil
+}
+
+var (
+	scriptsMu         sync.Mutex
+	loadedScriptTypes []string // 当前已注册的脚本站点类型
+)
+
+// ReloadParserScripts 重新加载脚本目录并替换之前注册的全部脚本解析器
+// 返回成功加载的脚本名称；加载失败的脚本不会注册，错误一并返回
+func ReloadParserScripts(dir string) ([]string, error) {
+	scriptsMu.Lock()
+	defer scriptsMu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.js"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var scripts []*ParserScript
+	var errs []error
+	for _, file := range files {
+		script, err := LoadParserScript(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		scripts = append(scripts, script)
+	}
+
+	for _, siteType := range loadedScriptTypes {
+		Unregister(siteType)
+		UnregisterHostMatchers(siteType)
+	}
+	loadedScriptTypes = nil
+
+	var names []string
+	for _, script := range scripts {
+		Register(script.SiteType(), func(reqClient *request.Client, cfg types.ConfigProvider) types.ImageCrawler {
+			return NewScriptCrawler(reqClient, script)
+		})
+		RegisterURLMatcher(script.SiteType(), script.Match)
+		loadedScriptTypes = append(loadedScriptTypes, script.SiteType())
+		names = append(names, script.Name)
+		logger.Info("已加载解析脚本 %s (%s)", script.Name, script.File)
+	}
+
+	loadErr := errors.Join(errs...)
+	if loadErr != nil {
+		logger.Warn("部分解析脚本加载失败: %v", loadErr)
+	}
+	return names, loadErr
+}
+
+// ScriptParser 基于用户脚本的解析器
+type ScriptParser struct {
+	script *ParserScript
+	ctx    context.Context
+}
+
+// SetContext 注入上下文，任务取消时中断脚本执行
+func (p *ScriptParser) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
+// GetName 获取解析器名称
+func (p *ScriptParser) GetName() string {
+	return "Script(" + p.script.Name + ")"
+}
+
+// Parse 解析URL获取图片信息
+func (p *ScriptParser) Parse(reqClient *request.Client, url string) (*ParseResult, error) {
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return p.script.Parse(ctx, reqClient, url)
+}
+
+// ScriptCrawler 基于用户脚本的爬虫
+type ScriptCrawler struct {
+	*BaseCrawler
+}
+
+// NewScriptCrawler 创建脚本爬虫
+func NewScriptCrawler(reqClient *request.Client, script *ParserScript) types.ImageCrawler {
+	parser := &ScriptParser{script: script}
+	baseCrawler := NewBaseCrawler(reqClient, parser)
+	return &ScriptCrawler{
+		BaseCrawler: baseCrawler,
+	}
+}
@@ -0,0 +1,98 @@
+package parsers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ImageMaster/core/request"
+)
+
+func writeScript(t *testing.T, name, source string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestParserScriptParse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<h1>Demo Gallery</h1><div id="pics"><img data-src="/a.png"><img data-src="/b.png"></div>`)
+	}))
+	defer server.Close()
+
+	file := writeScript(t, "demo.js", `
+function match(url) { return url.indexOf("/gallery/") >= 0; }
+function parse(url) {
+	var html = fetch(url);
+	var base = url.replace(/\/gallery\/.*$/, "");
+	var images = select(html, "#pics img", "data-src").map(function (src) { return base + src; });
+	log("found", images.length);
+	return { name: select(html, "h1")[0].text, images: images, headers: { Referer: url } };
+}`)
+
+	script, err := LoadParserScript(file)
+	if err != nil {
+		t.Fatalf("LoadParserScript() error = %v", err)
+	}
+	if !script.Match(server.URL+"/gallery/1") || script.Match(server.URL+"/other") {
+		t.Error("Match() returned unexpected result")
+	}
+
+	result, err := script.Parse(context.Background(), request.NewClient(), server.URL+"/gallery/1")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if result.Name != "Demo Gallery" {
+		t.Errorf("Name = %q, expected %q", result.Name, "Demo Gallery")
+	}
+	if len(result.ImageURLs) != 2 || result.ImageURLs[1] != server.URL+"/b.png" {
+		t.Errorf("ImageURLs = %v", result.ImageURLs)
+	}
+	if result.Headers["Referer"] != server.URL+"/gallery/1" {
+		t.Errorf("Headers = %v", result.Headers)
+	}
+}
+
+func TestParserScriptTimeoutAndCancel(t *testing.T) {
+	file := writeScript(t, "loop.js", `
+var timeout = 0.2;
+function match(url) { return true; }
+function parse(url) { while (true) {} }`)
+
+	script, err := LoadParserScript(file)
+	if err != nil {
+		t.Fatalf("LoadParserScript() error = %v", err)
+	}
+
+	start := time.Now()
+	_, err = script.Parse(context.Background(), request.NewClient(), "https://example.com/")
+	if err == nil || !strings.Contains(err.Error(), "超时") {
+		t.Fatalf("Parse() error = %v, expected timeout", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Parse() took %v, expected to stop near the script timeout", time.Since(start))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = script.Parse(ctx, request.NewClient(), "https://example.com/")
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Fatalf("Parse() error = %v, expected cancellation", err)
+	}
+}
+
+func TestLoadParserScriptMissingFunction(t *testing.T) {
+	file := writeScript(t, "broken.js", `function match(url) { return true; }`)
+	if _, err := LoadParserScript(file); err == nil || !strings.Contains(err.Error(), "parse") {
+		t.Fatalf("LoadParserScript() error = %v, expected missing parse", err)
+	}
+}
@@ -0,0 +1,469 @@
+package parsers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+
+	"ImageMaster/core/logger"
+	"ImageMaster/core/request"
+	"ImageMaster/core/types"
+	"ImageMaster/core/utils"
+)
+
+// SiteTypeRulePrefix 声明式规则注册的站点类型前缀，如 rule:example
+const SiteTypeRulePrefix = "rule:"
+
+// ruleDetailConcurrency 同时访问的详情页数量
+const ruleDetailConcurrency = 8
+
+// extensionNamePattern 规则/脚本名称只允许字母、数字、下划线和中划线
+var extensionNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// SiteRule 声明式站点规则（JSON 格式，放置于配置文件旁的规则目录中）
+type SiteRule struct {
+	Name               string            `json:"name"`                // 规则名称
+	Hosts              []string          `json:"hosts"`               // host 匹配模式，支持 *.example.com
+	TitleSelector      string            `json:"title_selector"`      // 标题选择器
+	TitleAttr          string            `json:"title_attr"`          // 标题属性，为空时取文本
+	PageListSelector   string            `json:"page_list_selector"`  // 详情页链接选择器（可选，每个详情页包含一张图片）
+	PaginationSelector string            `json:"pagination_selector"` // 分页链接选择器（可选）
+	ImageSelector      string            `json:"image_selector"`      // 图片选择器
+	ImageAttr          string            `json:"image_attr"`          // 图片地址属性，默认 src
+	Headers            map[string]string `json:"headers"`             // 附加请求头（可选）
+	Cookies            map[string]string `json:"cookies"`             // 附加 Cookie（可选）
+
+	source string // 规则来源文件
+}
+
+// SiteType 获取规则对应的站点类型
+func (r *SiteRule) SiteType() string {
+	return SiteTypeRulePrefix + r.Name
+}
+
+// Validate 校验规则，返回第一个发现的问题
+func (r *SiteRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("缺少字段 name")
+	}
+	if !extensionNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("字段 name 只能包含字母、数字、下划线和中划线: %q", r.Name)
+	}
+	if err := validateHostPatterns(r.Hosts); err != nil {
+		return err
+	}
+
+	selectors := []struct {
+		field    string
+		value    string
+		required bool
+	}{
+		{"title_selector", r.TitleSelector, true},
+		{"page_list_selector", r.PageListSelector, false},
+		{"pagination_selector", r.PaginationSelector, false},
+		{"image_selector", r.ImageSelector, true},
+	}
+	for _, sel := range selectors {
+		if sel.value == "" {
+			if sel.required {
+				return fmt.Errorf("缺少字段 %s", sel.field)
+			}
+			continue
+		}
+		if _, err := cascadia.ParseGroup(sel.value); err != nil {
+			return fmt.Errorf("字段 %s 不是有效的 CSS 选择器 %q: %v", sel.field, sel.value, err)
+		}
+	}
+	return nil
+}
+
+// MatchHost 判断 host 是否匹配规则
+func (r *SiteRule) MatchHost(host string) bool {
+	return matchHostPatterns(r.Hosts, host)
+}
+
+// validateHostPatterns 校验 hosts 字段
+func validateHostPatterns(patterns []string) error {
+	if len(patterns) == 0 {
+		return fmt.Errorf("缺少字段 hosts")
+	}
+	for i, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("字段 hosts[%d] 为空", i)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("字段 hosts[%d] 不是有效的匹配模式: %q", i, pattern)
+		}
+	}
+	return nil
+}
+
+// matchHostPatterns 判断 host 是否匹配任一模式
+// 含 * 的模式按通配符匹配，否则匹配域名本身及其子域名
+func matchHostPatterns(patterns []string, host string) bool {
+	host = strings.ToLower(host)
+	if h, _, found := strings.Cut(host, ":"); found {
+		host = h
+	}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if strings.Contains(pattern, "*") {
+			if ok, _ := path.Match(pattern, host); ok {
+				return true
+			}
+			continue
+		}
+		if host == pattern || strings.HasSuffix(host, "."+pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadSiteRule 从文件加载并校验单条规则
+func LoadSiteRule(file string) (*SiteRule, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("规则文件 %s: 读取失败: %w", file, err)
+	}
+
+	var rule SiteRule
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rule); err != nil {
+		return nil, fmt.Errorf("规则文件 %s: JSON 格式错误: %w", file, err)
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, fmt.Errorf("规则文件 %s: %w", file, err)
+	}
+	rule.source = file
+	return &rule, nil
+}
+
+// LoadSiteRules 加载目录下全部 *.json 规则
+// 有问题的规则会被跳过，其错误合并后返回
+func LoadSiteRules(dir string) ([]*SiteRule, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var rules []*SiteRule
+	var errs []error
+	names := make(map[string]string)
+	for _, file := range files {
+		rule, err := LoadSiteRule(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if prev, exists := names[rule.Name]; exists {
+			errs = append(errs, fmt.Errorf("规则文件 %s: 名称 %q 与 %s 重复", file, rule.Name, prev))
+			continue
+		}
+		names[rule.Name] = file
+		rules = append(rules, rule)
+	}
+	return rules, errors.Join(errs...)
+}
+
+var (
+	siteRulesMu     sync.Mutex
+	loadedRuleTypes []string // 当前已注册的规则站点类型
+)
+
+// ReloadSiteRules 重新加载规则目录并替换之前注册的全部规则
+// 返回成功加载的规则名称；校验失败的规则不会注册，错误一并返回
+func ReloadSiteRules(dir string) ([]string, error) {
+	siteRulesMu.Lock()
+	defer siteRulesMu.Unlock()
+
+	rules, loadErr := LoadSiteRules(dir)
+
+	for _, siteType := range loadedRuleTypes {
+		Unregister(siteType)
+		UnregisterHostMatchers(siteType)
+	}
+	loadedRuleTypes = nil
+
+	var names []string
+	for _, rule := range rules {
+		Register(rule.SiteType(), func(reqClient *request.Client, cfg types.ConfigProvider) types.ImageCrawler {
+			return NewRuleCrawler(reqClient, rule)
+		})
+		RegisterHostMatcher(rule.SiteType(), rule.MatchHost)
+		loadedRuleTypes = append(loadedRuleTypes, rule.SiteType())
+		names = append(names, rule.Name)
+		logger.Info("已加载站点规则 %s (%s)", rule.Name, rule.source)
+	}
+	if loadErr != nil {
+		logger.Warn("部分站点规则加载失败: %v", loadErr)
+	}
+	return names, loadErr
+}
+
+// RuleParser 基于声明式规则的解析器
+type RuleParser struct {
+	rule *SiteRule
+	ctx  context.Context
+}
+
+// NewRuleParser 创建规则解析器
+func NewRuleParser(rule *SiteRule) *RuleParser {
+	return &RuleParser{rule: rule}
+}
+
+// SetContext 注入上下文以支持取消
+func (p *RuleParser) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
+// GetName 获取解析器名称
+func (p *RuleParser) GetName() string {
+	return "Rule(" + p.rule.Name + ")"
+}
+
+// Parse 解析URL获取图片信息
+func (p *RuleParser) Parse(reqClient *request.Client, pageURL string) (*ParseResult, error) {
+	rule := p.rule
+	firstURL, doc, err := p.fetch(reqClient, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取标题
+	titleSel := doc.Find(rule.TitleSelector).First()
+	title := titleSel.Text()
+	if rule.TitleAttr != "" {
+		title = titleSel.AttrOr(rule.TitleAttr, "")
+	}
+	title = strings.TrimSpace(strings.ReplaceAll(title, "/", "_"))
+	if title == "" {
+		title = firstURL.Hostname()
+	}
+
+	// 收集列表页（首页 + 分页）
+	listDocs := []*goquery.Document{doc}
+	if rule.PaginationSelector != "" {
+		for _, link := range dedupeStrings(p.collectLinks(doc, firstURL, rule.PaginationSelector, "href")) {
+			if link == firstURL.String() {
+				continue
+			}
+			if err := p.checkContext(); err != nil {
+				return nil, err
+			}
+			_, pageDoc, err := p.fetch(reqClient, link)
+			if err != nil {
+				logger.Warn("获取分页失败 %s: %v", link, err)
+				continue
+			}
+			listDocs = append(listDocs, pageDoc)
+		}
+	}
+
+	var imgURLs []string
+	var pageNumbers []int
+	if rule.PageListSelector == "" {
+		for _, listDoc := range listDocs {
+			imgURLs = append(imgURLs, p.collectLinks(listDoc, firstURL, rule.ImageSelector, p.imageAttr())...)
+		}
+		imgURLs = dedupeStrings(imgURLs)
+	} else {
+		var detailLinks []string
+		for _, listDoc := range listDocs {
+			detailLinks = append(detailLinks, p.collectLinks(listDoc, firstURL, rule.PageListSelector, "href")...)
+		}
+		imgURLs, pageNumbers = p.resolveDetailPages(reqClient, dedupeStrings(detailLinks))
+		if err := p.checkContext(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(imgURLs) == 0 {
+		return nil, fmt.Errorf("规则 %s 未匹配到任何图片", rule.Name)
+	}
+
+	result := &ParseResult{
+		Name:        title,
+		ImageURLs:   imgURLs,
+		PageNumbers: pageNumbers,
+		Headers:     p.downloadHeaders(),
+	}
+	result.FilePaths = make([]string, len(imgURLs))
+	for i, imgURL := range imgURLs {
+		result.FilePaths[i] = fmt.Sprintf("%03d%s", result.PageNumber(i), genericExt(imgURL))
+	}
+	return result, nil
+}
+
+// resolveDetailPages 并发访问详情页获取图片地址，保持原有顺序
+// 同时访问的详情页数量受 ruleDetailConcurrency 限制，上下文取消后不再发起新请求
+// 返回图片地址及其对应的详情页序号（从 1 开始），获取失败的详情页不会让后续页码前移
+func (p *RuleParser) resolveDetailPages(reqClient *request.Client, links []string) ([]string, []int) {
+	results := make([]string, len(links))
+	sem := utils.NewSemaphore(ruleDetailConcurrency)
+	var wg sync.WaitGroup
+	for i, link := range links {
+		if p.ctx != nil {
+			if err := sem.AcquireWithContext(p.ctx); err != nil {
+				break
+			}
+		} else {
+			sem.Acquire()
+		}
+		wg.Add(1)
+		go func(idx int, link string) {
+			defer wg.Done()
+			defer sem.Release()
+			if p.checkContext() != nil {
+				return
+			}
+			pageURL, doc, err := p.fetch(reqClient, link)
+			if err != nil {
+				logger.Warn("获取详情页失败 %s: %v", link, err)
+				return
+			}
+			if imgs := p.collectLinks(doc, pageURL, p.rule.ImageSelector, p.imageAttr()); len(imgs) > 0 {
+				results[idx] = imgs[0]
+			}
+		}(i, link)
+	}
+	wg.Wait()
+
+	var imgURLs []string
+	var pageNumbers []int
+	seen := make(map[string]bool, len(results))
+	for i, imgURL := range results {
+		if imgURL != "" && !seen[imgURL] {
+			seen[imgURL] = true
+			imgURLs = append(imgURLs, imgURL)
+			pageNumbers = append(pageNumbers, i+1)
+		}
+	}
+	return imgURLs, pageNumbers
+}
+
+// fetch 获取并解析页面
+func (p *RuleParser) fetch(reqClient *request.Client, pageURL string) (*url.URL, *goquery.Document, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无效的URL: %w", err)
+	}
+	resp, err := reqClient.RateLimitedGetWithOptions(pageURL, p.requestOptions())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("HTTP状态码错误: %d", resp.StatusCode)
+	}
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, doc, nil
+}
+
+// requestOptions 规则附加的请求头与 Cookie，只作用于规则发出的请求
+func (p *RuleParser) requestOptions() request.Options {
+	opts := request.Options{Headers: p.rule.Headers}
+	for name, value := range p.rule.Cookies {
+		opts.Cookies = append(opts.Cookies, &http.Cookie{Name: name, Value: value})
+	}
+	return opts
+}
+
+// downloadHeaders 下载图片时附加的请求头，规则中的 Cookie 合并为 Cookie 请求头
+func (p *RuleParser) downloadHeaders() map[string]string {
+	if len(p.rule.Cookies) == 0 {
+		return p.rule.Headers
+	}
+	headers := make(map[string]string, len(p.rule.Headers)+1)
+	for k, v := range p.rule.Headers {
+		headers[k] = v
+	}
+	names := make([]string, 0, len(p.rule.Cookies))
+	for name := range p.rule.Cookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var cookies []string
+	if existing := headers["Cookie"]; existing != "" {
+		cookies = append(cookies, existing)
+	}
+	for _, name := range names {
+		cookies = append(cookies, (&http.Cookie{Name: name, Value: p.rule.Cookies[name]}).String())
+	}
+	headers["Cookie"] = strings.Join(cookies, "; ")
+	return headers
+}
+
+// collectLinks 按选择器读取属性并解析为绝对地址
+func (p *RuleParser) collectLinks(doc *goquery.Document, base *url.URL, selector, attr string) []string {
+	var links []string
+	doc.Find(selector).Each(func(i int, s *goquery.Selection) {
+		if v, ok := s.Attr(attr); ok {
+			if abs := resolveImageURL(base, v); abs != "" {
+				links = append(links, abs)
+			}
+		}
+	})
+	return links
+}
+
+func (p *RuleParser) imageAttr() string {
+	if p.rule.ImageAttr != "" {
+		return p.rule.ImageAttr
+	}
+	return "src"
+}
+
+func (p *RuleParser) checkContext() error {
+	if p.ctx != nil {
+		return p.ctx.Err()
+	}
+	return nil
+}
+
+// dedupeStrings 去重并保持顺序
+func dedupeStrings(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// RuleCrawler 基于声明式规则的爬虫
+type RuleCrawler struct {
+	*BaseCrawler
+}
+
+// NewRuleCrawler 创建规则爬虫
+func NewRuleCrawler(reqClient *request.Client, rule *SiteRule) types.ImageCrawler {
+	parser := NewRuleParser(rule)
+	baseCrawler := NewBaseCrawler(reqClient, parser)
+	return &RuleCrawler{
+		BaseCrawler: baseCrawler,
+	}
+}
@@ -0,0 +1,103 @@
+package parsers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ImageMaster/core/request"
+)
+
+func TestSiteRuleMatchHost(t *testing.T) {
+	rule := &SiteRule{Hosts: []string{"example.com", "*.cdn-site.net"}}
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{"example.com", true},
+		{"www.example.com", true},
+		{"EXAMPLE.com:8080", true},
+		{"notexample.com", false},
+		{"img.cdn-site.net", true},
+		{"cdn-site.net", false},
+	}
+	for _, tt := range tests {
+		if got := rule.MatchHost(tt.host); got != tt.expected {
+			t.Errorf("MatchHost(%q) = %v, expected %v", tt.host, got, tt.expected)
+		}
+	}
+}
+
+func TestLoadSiteRules(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"good.json":         `{"name": "good", "hosts": ["good.com"], "title_selector": "h1", "image_selector": "#content img"}`,
+		"bad_css.json":      `{"name": "bad_css", "hosts": ["bad.com"], "title_selector": "h1", "image_selector": "img[src"}`,
+		"no_hosts.json":     `{"name": "no_hosts", "title_selector": "h1", "image_selector": "img"}`,
+		"unknown.json":      `{"name": "unknown", "hosts": ["x.com"], "title_selector": "h1", "image_selector": "img", "imgae_attr": "src"}`,
+		"zz_duplicate.json": `{"name": "good", "hosts": ["other.com"], "title_selector": "h1", "image_selector": "img"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules, err := LoadSiteRules(dir)
+	if len(rules) != 1 || rules[0].Name != "good" || rules[0].Hosts[0] != "good.com" {
+		t.Fatalf("LoadSiteRules() loaded %+v, expected only rule \"good\"", rules)
+	}
+	if err == nil {
+		t.Fatal("LoadSiteRules() expected validation errors")
+	}
+	for _, want := range []string{"bad_css.json", "image_selector", "no_hosts.json", "hosts", "unknown.json", "imgae_attr", "zz_duplicate.json"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err.Error(), want)
+		}
+	}
+}
+
+func TestRuleParserDetailPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("sid"); err != nil || c.Value != "abc" {
+			http.Error(w, "no cookie", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/gallery":
+			fmt.Fprint(w, `<h1>Demo</h1><a class="p" href="/p/1">1</a><a class="p" href="/p/2">2</a><a class="p" href="/p/3">3</a>`)
+		case "/p/2":
+			http.NotFound(w, r)
+		default:
+			fmt.Fprintf(w, `<img id="main" src="/img%s.png">`, strings.TrimPrefix(r.URL.Path, "/p/"))
+		}
+	}))
+	defer server.Close()
+
+	parser := NewRuleParser(&SiteRule{
+		Name:             "demo",
+		TitleSelector:    "h1",
+		PageListSelector: "a.p",
+		ImageSelector:    "#main",
+		Cookies:          map[string]string{"sid": "abc"},
+	})
+	result, err := parser.Parse(request.NewClient(), server.URL+"/gallery")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	// 第 2 页获取失败，第 3 页仍保留原页码
+	if len(result.ImageURLs) != 2 || result.ImageURLs[1] != server.URL+"/img3.png" {
+		t.Errorf("ImageURLs = %v", result.ImageURLs)
+	}
+	if fmt.Sprint(result.PageNumbers) != "[1 3]" || strings.Join(result.FilePaths, ",") != "001.png,003.png" {
+		t.Errorf("PageNumbers = %v, FilePaths = %v", result.PageNumbers, result.FilePaths)
+	}
+	if result.Headers["Cookie"] != "sid=abc" {
+		t.Errorf("Headers = %v", result.Headers)
+	}
+}
@@ -132,4 +132,5 @@ func init() {
 		return NewTelegraphCrawler(reqClient)
 	})
 	RegisterHostContains(SiteTypeTelegraph, "telegra.ph", "telegraph.com")
+	request.RegisterRateLimit(SiteTypeTelegraph, types.RateLimitPolicy{RequestsPerSecond: 5, Burst: 10})
 }
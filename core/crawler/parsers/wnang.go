@@ -3,23 +3,38 @@ package parsers
 import (
 	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 
 	"ImageMaster/core/request"
 	"ImageMaster/core/types"
+	"ImageMaster/core/utils"
 )
 
 // WnacgAlbum Wnacg专辑
 type WnacgAlbum struct {
-	Name  string
-	Pages []string // 存储所有分页的URL
+	Name     string
+	Pages    []string // 存储所有分页的URL
+	Metadata *GalleryMetadata
 }
 
+// wnacgDatePattern 匹配上传日期
+var wnacgDatePattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
 // WnacgParser Wnacg解析器实现
-type WnacgParser struct{}
+type WnacgParser struct {
+	pages *utils.PageSelection // 页码选择，未选中的页不解析
+}
+
+// SetPageSelection 设置页码选择
+func (p *WnacgParser) SetPageSelection(pages *utils.PageSelection) {
+	p.pages = pages
+}
 
 // GetName 获取解析器名称
 func (p *WnacgParser) GetName() string {
@@ -33,45 +48,47 @@ func (p *WnacgParser) Parse(reqClient *request.Client, url string) (*ParseResult
 		return nil, fmt.Errorf("获取专辑失败: %w", err)
 	}
 
-	// 批量下载URL和路径
-	var imgURLs []string
-	var filePaths []string
-	var urlMutex sync.Mutex
-
-	// 收集所有漫画页面链接
-	var allMangaLinks []string
+	// 收集所有漫画页面链接，页码从 1 开始
+	type wnacgImageLink struct {
+		indexPart  string
+		pageNumber int
+		url        string
+	}
+	var allMangaLinks []wnacgImageLink
+	pageNumber := 0
 	for pageIndex, pageURL := range wnacgAlbum.Pages {
+		// 任一分页失败都会让后续页码错位，直接返回错误
 		links, err := GetMangaLinksFromPage(reqClient, pageURL)
 		if err != nil {
-			fmt.Printf("获取分页 %s 的漫画链接失败: %v\n", pageURL, err)
-			continue
+			return nil, fmt.Errorf("获取分页 %s 的漫画链接失败: %w", pageURL, err)
 		}
 
-		// 为每个链接添加页面索引信息
+		// 为每个链接添加页面索引信息，未选中的页不解析
 		for linkIndex, link := range links {
-			allMangaLinks = append(allMangaLinks, fmt.Sprintf("%d_%d|%s", pageIndex, linkIndex, link))
+			pageNumber++
+			if !p.pages.Contains(pageNumber) {
+				continue
+			}
+			allMangaLinks = append(allMangaLinks, wnacgImageLink{
+				indexPart:  fmt.Sprintf("%d_%d", pageIndex, linkIndex),
+				pageNumber: pageNumber,
+				url:        link,
+			})
 		}
 	}
 
 	totalMangaLinks := len(allMangaLinks)
 	fmt.Printf("总共需要处理 %d 个漫画页面\n", totalMangaLinks)
 
-	// 并发处理控制
+	// 按链接顺序保存结果，解析失败的留空
+	imgURLs := make([]string, totalMangaLinks)
 	var wg sync.WaitGroup
 
 	// 并发处理所有漫画页面链接
-	for _, mangaData := range allMangaLinks {
-		// 解析页面索引和URL
-		parts := strings.SplitN(mangaData, "|", 2)
-		if len(parts) != 2 {
-			continue
-		}
-		indexPart := parts[0]
-		mangaURL := parts[1]
-
+	for i, mangaLink := range allMangaLinks {
 		wg.Add(1)
 
-		go func(indexPart, mangaURL string) {
+		go func(idx int, mangaURL string) {
 			defer wg.Done()
 
 			// 解析漫画页面获取真实图片URL
@@ -80,27 +97,27 @@ func (p *WnacgParser) Parse(reqClient *request.Client, url string) (*ParseResult
 				fmt.Printf("解析漫画页面失败 %s: %v\n", mangaURL, err)
 				return
 			}
+			imgURLs[idx] = imgURL
 
-			// 构建保存文件名
-			filename := fmt.Sprintf("%s.jpg", indexPart)
-
-			urlMutex.Lock()
-			imgURLs = append(imgURLs, imgURL)
-			filePaths = append(filePaths, filename)
-			urlMutex.Unlock()
-
-			fmt.Printf("解析完成 %s\n", filename)
-		}(indexPart, mangaURL)
+			fmt.Printf("解析完成 %s\n", mangaURL)
+		}(i, mangaLink.url)
 	}
 
 	// 等待所有URL收集任务完成
 	wg.Wait()
 
-	return &ParseResult{
-		Name:      wnacgAlbum.Name,
-		ImageURLs: imgURLs,
-		FilePaths: filePaths,
-	}, nil
+	// 按画廊顺序整理结果，文件名保留原始位置
+	result := &ParseResult{Name: wnacgAlbum.Name, Metadata: wnacgAlbum.Metadata}
+	for i, mangaLink := range allMangaLinks {
+		if imgURLs[i] == "" {
+			continue
+		}
+		result.ImageURLs = append(result.ImageURLs, imgURLs[i])
+		result.FilePaths = append(result.FilePaths, fmt.Sprintf("%s.jpg", mangaLink.indexPart))
+		result.PageNumbers = append(result.PageNumbers, mangaLink.pageNumber)
+	}
+
+	return result, nil
 }
 
 // GetWnacgAlbumWithClient 获取整个专辑信息，包括所有分页URL
@@ -153,6 +170,11 @@ func GetWnacgAlbumWithClient(reqClient *request.Client, url string) (*WnacgAlbum
 		}
 	})
 
+	// 画廊元数据只在第一页
+	metadata := parseWnacgMetadata(doc)
+	metadata.Title = albumName
+	metadata.SourceURL = url
+
 	resp.Body.Close()
 
 	if albumName == "" {
@@ -170,11 +192,49 @@ func GetWnacgAlbumWithClient(reqClient *request.Client, url string) (*WnacgAlbum
 	}
 
 	return &WnacgAlbum{
-		Name:  albumName,
-		Pages: uniqueURLs,
+		Name:     albumName,
+		Pages:    uniqueURLs,
+		Metadata: metadata,
 	}, nil
 }
 
+// parseWnacgMetadata 解析画廊首页的分类、页数、上传日期与标签
+func parseWnacgMetadata(doc *goquery.Document) *GalleryMetadata {
+	metadata := &GalleryMetadata{}
+
+	// 信息栏形如 "分類：同人誌 / 漢化"、"頁數：24P"
+	doc.Find(".asTBcell.uwconn label").Each(func(i int, s *goquery.Selection) {
+		label, value, found := strings.Cut(strings.TrimSpace(s.Text()), "：")
+		if !found {
+			return
+		}
+		value = strings.TrimSpace(value)
+		switch label {
+		case "分類", "分类":
+			metadata.Category = value
+			if strings.Contains(value, "漢化") || strings.Contains(value, "汉化") {
+				metadata.Language = "chinese"
+			}
+		case "頁數", "页数":
+			metadata.PageCount, _ = strconv.Atoi(strings.TrimRight(value, "Pp"))
+		}
+	})
+
+	// 上传日期形如 "上傳於2023-01-02"
+	if m := wnacgDatePattern.FindString(doc.Find(".asTBcell.uwconn p").Text()); m != "" {
+		if t, err := time.Parse("2006-01-02", m); err == nil {
+			metadata.UploadDate = t
+		}
+	}
+
+	doc.Find(".addtags a.tagshow").Each(func(i int, s *goquery.Selection) {
+		metadata.addNamespacedTag("tag", s.Text())
+	})
+
+	metadata.Manga = mangaForCategory(metadata.Category)
+	return metadata
+}
+
 // GetMangaLinksFromPage 从分页中获取所有漫画页面的链接
 func GetMangaLinksFromPage(reqClient *request.Client, pageURL string) ([]string, error) {
 	resp, err := reqClient.RateLimitedGet(pageURL)
@@ -269,4 +329,5 @@ func init() {
 		return NewWnacgCrawler(reqClient)
 	})
 	RegisterHostContains(SiteTypeWnacg, "wnacg.com")
+	request.RegisterRateLimit(SiteTypeWnacg, types.RateLimitPolicy{RequestsPerSecond: 2, Burst: 4})
 }
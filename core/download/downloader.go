@@ -2,12 +2,12 @@ package download
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"ImageMaster/core/request"
@@ -28,7 +28,10 @@ type Downloader struct {
 	showProcess   bool
 	configManager types.ConfigProvider
 	taskUpdater   types.TaskUpdater // 任务更新器
-	semaphore     *utils.Semaphore  // 用于控制并发数量的信号量
+	siteType      string            // 站点类型，用于选择重试策略
+	concurrency   int               // 并发下载数量
+	pool          *workerPool       // 当前批量下载的协程池
+	meter         *progressMeter    // 字节数与速度统计
 	mu            sync.RWMutex
 	ctx           context.Context
 }
@@ -38,19 +41,23 @@ type Config struct {
 	RetryCount  int
 	RetryDelay  int // 秒
 	ShowProcess bool
+	Concurrency int // 并发下载数量，0 表示使用默认值
 }
 
 // NewDownloader 创建新的下载器
 func NewDownloader(config Config) *Downloader {
-	// 创建用于控制并发数量的信号量
-	semaphore := utils.NewSemaphore(DefaultDownloadConcurrency)
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultDownloadConcurrency
+	}
 
 	return &Downloader{
 		reqClient:   request.NewClient(),
 		retryCount:  config.RetryCount,
 		retryDelay:  time.Duration(config.RetryDelay) * time.Second,
 		showProcess: config.ShowProcess,
-		semaphore:   semaphore,
+		concurrency: concurrency,
+		meter:       &progressMeter{},
 	}
 }
 
@@ -71,6 +78,30 @@ func (d *Downloader) GetTaskUpdater() types.TaskUpdater {
 	return d.taskUpdater
 }
 
+// SetSiteType 设置下载的站点类型
+func (d *Downloader) SetSiteType(siteType string) {
+	d.siteType = siteType
+}
+
+// SetConcurrency 设置并发下载数量，正在进行的批量下载立即按新数量调整
+func (d *Downloader) SetConcurrency(n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.concurrency = max(n, 1)
+	if d.pool != nil {
+		d.pool.resize(d.concurrency)
+	}
+}
+
+// SetItemPriority 调整尚未开始下载的图片的优先级，数值越大越先下载
+// 返回图片是否仍在等待下载
+func (d *Downloader) SetItemPriority(url string, priority int) bool {
+	d.mu.RLock()
+	pool := d.pool
+	d.mu.RUnlock()
+	return pool != nil && pool.setPriority(url, priority)
+}
+
 // GetProxy 获取当前代理设置
 func (d *Downloader) GetProxy() string {
 	return d.configManager.GetProxy()
@@ -93,29 +124,44 @@ func (d *Downloader) SetContext(ctx context.Context) {
 
 // DownloadFile 下载文件到指定路径
 func (d *Downloader) DownloadFile(url string, filePath string, headers map[string]string) error {
+	_, _, err := d.downloadFile(url, filePath, headers)
+	return err
+}
+
+// downloadFile 下载文件，返回实际保存路径（扩展名按图片格式修正）与发出请求的次数
+func (d *Downloader) downloadFile(url string, filePath string, headers map[string]string) (string, int, error) {
 	if d.ctx != nil {
 		if err := d.ctx.Err(); err != nil {
-			return err
+			return "", 0, err
 		}
 	}
 	filePath = utils.NormalizePath(filePath)
+
+	// 已存在且校验通过的文件直接跳过，重新运行任务时只下载缺失部分
+	if existing, ok := findExistingImage(filePath); ok {
+		fmt.Printf("文件已存在，跳过下载: %s\n", existing)
+		d.meter.addSkipped()
+		return existing, 0, nil
+	}
+
 	// 确保目录存在
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("创建目录失败: %w", err)
+		return "", 0, fmt.Errorf("创建目录失败: %w", err)
 	}
 
-	// 直接创建最终文件
-	out, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("创建文件失败: %w", err)
-	}
-	defer out.Close()
+	// 先写入临时文件，成功后再重命名，避免崩溃时留下不完整的图片
+	// 上次遗留的临时文件在可校验时续传
+	partPath := filePath + PartSuffix
+	state := loadPartState(partPath, url)
 
-	// 执行下载
+	// 执行下载，暂时性错误按策略退避后重试，永久性错误立即放弃
+	policy := d.retryPolicy()
 	success := false
 	var lastErr error
-	for attempt := 0; attempt <= d.retryCount; attempt++ {
+	var resumedBytes int64
+	attempts := 0
+	for attempt := 0; attempt <= policy.MaxRetries; attempt++ {
 		if d.ctx != nil {
 			if err := d.ctx.Err(); err != nil {
 				lastErr = err
@@ -123,71 +169,101 @@ func (d *Downloader) DownloadFile(url string, filePath string, headers map[strin
 			}
 		}
 		if attempt > 0 {
-			fmt.Printf("重试下载 %s (第 %d 次)\n", url, attempt)
-			time.Sleep(d.retryDelay)
-		}
-
-		// 设置请求头
-		if headers != nil {
-			d.reqClient.SetHeaders(headers)
+			if isPermanentError(lastErr) {
+				break
+			}
+			wait := retryWait(policy, attempt, lastErr)
+			fmt.Printf("重试下载 %s (第 %d 次，%v 后，已有 %d 字节): %v\n", url, attempt, wait, state.offset, lastErr)
+			if err := sleepContext(d.ctx, wait); err != nil {
+				lastErr = err
+				break
+			}
 		}
 
-		// 执行请求
-		resp, err := d.reqClient.Get(url)
+		// 执行请求并写入临时文件
+		attempts++
+		resumed, err := d.fetchToPart(url, partPath, state, headers)
 		if err != nil {
-			lastErr = fmt.Errorf("请求失败: %w", err)
+			lastErr = err
 			continue
 		}
 
-		// 检查状态码
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			lastErr = fmt.Errorf("状态码错误: %d", resp.StatusCode)
-			continue
-		}
-
-		// 清空文件内容
-		if _, err := out.Seek(0, 0); err != nil {
-			resp.Body.Close()
-			lastErr = fmt.Errorf("文件定位失败: %w", err)
-			continue
-		}
-		if err := out.Truncate(0); err != nil {
-			resp.Body.Close()
-			lastErr = fmt.Errorf("清空文件失败: %w", err)
-			continue
-		}
-
-		// 复制数据
-		_, err = io.Copy(out, resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			lastErr = fmt.Errorf("数据写入失败: %w", err)
+		// 校验下载结果，错误页、截断的文件与占位图丢弃后重试
+		if err := verifyImageFile(partPath, d.placeholderHashes()); err != nil {
+			state.reset(partPath)
+			lastErr = err
 			continue
 		}
+		resumedBytes += resumed
 
 		success = true
 		break
 	}
 
 	if !success {
-		// 下载失败时删除文件
-		os.Remove(filePath)
-		return fmt.Errorf("下载失败: %w", lastErr)
+		// 可续传的部分数据保留到下次，其余删除
+		if state.offset == 0 || state.ifRange() == "" {
+			removePart(partPath)
+		}
+		return "", attempts, fmt.Errorf("下载失败: %w", lastErr)
 	}
 
-	return nil
+	// 按实际格式修正扩展名后原子替换为最终文件
+	savedPath := correctImageExt(filePath, readHeader(partPath), state.ContentType)
+	if savedPath != filePath {
+		fmt.Printf("按图片格式修正扩展名: %s -> %s\n", filepath.Base(filePath), filepath.Base(savedPath))
+	}
+	if err := os.Rename(partPath, savedPath); err != nil {
+		removePart(partPath)
+		return "", attempts, fmt.Errorf("重命名文件失败: %w", err)
+	}
+	os.Remove(partPath + partMetaSuffix)
+
+	// 报告通过续传省下的流量
+	if resumedBytes > 0 && d.taskUpdater != nil {
+		d.taskUpdater.AddResumedBytes(resumedBytes)
+	}
+
+	return savedPath, attempts, nil
 }
 
 // DownloadResult 下载结果
 type DownloadResult struct {
-	Index   int
-	URL     string
-	Success bool
-	Error   error
+	Index     int
+	URL       string
+	FilePath  string
+	SavedPath string // 实际保存路径，扩展名可能与 FilePath 不同
+	Success   bool
+	Attempts  int
+	Bytes     int64
+	Error     error
 }
 
-// BatchDownload 批量下载文件（支持并行下载）
+// toItem 转换为单张图片的下载结果
+func (r DownloadResult) toItem() types.DownloadItem {
+	item := types.DownloadItem{
+		URL:       r.URL,
+		FilePath:  r.FilePath,
+		Status:    string(types.StatusCompleted),
+		Attempts:  r.Attempts,
+		Bytes:     r.Bytes,
+		SavedPath: r.SavedPath,
+	}
+	if !r.Success {
+		// 因取消或暂停而中断的图片不算失败，继续下载时重新获取
+		if errors.Is(r.Error, context.Canceled) {
+			item.Status = string(types.StatusPending)
+			return item
+		}
+		item.Status = string(types.StatusFailed)
+		if r.Error != nil {
+			item.Error = r.Error.Error()
+		}
+	}
+	return item
+}
+
+// BatchDownload 批量下载文件（固定数量的协程并行下载）
 func (d *Downloader) BatchDownload(urls []string, filepaths []string, headers map[string]string) (int, error) {
 	total := len(urls)
 	if total == 0 {
@@ -198,64 +274,43 @@ func (d *Downloader) BatchDownload(urls []string, filepaths []string, headers ma
 		return 0, fmt.Errorf("URL和文件路径数量不匹配")
 	}
 
-	// 创建结果通道
-	resultCh := make(chan DownloadResult, total)
-	var wg sync.WaitGroup
+	// 下载过程中定期上报字节进度与速度
+	d.meter.reset()
+	var completedCount atomic.Int64
+	done := make(chan struct{})
+	defer close(done)
+	go d.reportProgress(done, func() int { return int(completedCount.Load()) }, total)
 
-	// 启动并行下载任务
+	// 默认按列表顺序下载，靠前的图片（封面与前几页）先下载，便于尽早预览
+	jobs := make([]*downloadJob, total)
 	for i, url := range urls {
-		wg.Add(1)
-		go func(index int, downloadURL, filePath string) {
-			defer wg.Done()
-
-			// 获取信号量（支持取消）
-			if d.ctx != nil {
-				if err := d.semaphore.AcquireWithContext(d.ctx); err != nil {
-					resultCh <- DownloadResult{Index: index, URL: downloadURL, Success: false, Error: err}
-					return
-				}
-			} else {
-				d.semaphore.Acquire()
-			}
-			defer d.semaphore.Release() // 完成后释放信号量
-
-			// 取消检查（获得并发名额后再次检查）
-			if d.ctx != nil {
-				if err := d.ctx.Err(); err != nil {
-					resultCh <- DownloadResult{Index: index, URL: downloadURL, Success: false, Error: err}
-					return
-				}
-			}
-
-			// 添加日志验证并发控制
-			fmt.Printf("开始下载 [%d/%d]: %s (当前并发: %d/%d)\n",
-				index+1, len(urls), downloadURL,
-				d.semaphore.Used(), d.semaphore.Capacity())
-
-			// 执行下载
-			err := d.DownloadFile(downloadURL, filePath, headers)
-
-			// 发送结果
-			resultCh <- DownloadResult{
-				Index:   index,
-				URL:     downloadURL,
-				Success: err == nil,
-				Error:   err,
-			}
-		}(i, url, filepaths[i])
+		jobs[i] = &downloadJob{index: i, url: url, filePath: filepaths[i]}
 	}
 
-	// 等待所有任务完成
+	resultCh := make(chan DownloadResult)
+	d.mu.Lock()
+	var pool *workerPool
+	pool = newWorkerPool(d.concurrency, func(job *downloadJob) {
+		resultCh <- d.runJob(pool, job, total, headers)
+	})
+	d.pool = pool
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		d.pool = nil
+		d.mu.Unlock()
+	}()
+
+	pool.start(jobs)
 	go func() {
-		wg.Wait()
+		pool.wait()
 		close(resultCh)
 	}()
 
 	// 收集结果并更新进度
 	successCount := 0
-	completedCount := 0
 	for result := range resultCh {
-		completedCount++
+		completed := int(completedCount.Add(1))
 		if result.Success {
 			successCount++
 		} else {
@@ -264,18 +319,49 @@ func (d *Downloader) BatchDownload(urls []string, filepaths []string, headers ma
 
 		// 使用任务更新器更新进度
 		if d.taskUpdater != nil {
-			d.taskUpdater.UpdateTaskProgress(completedCount, total)
+			d.taskUpdater.UpdateItem(result.toItem())
+			d.taskUpdater.UpdateTaskProgress(completed, total)
 			// 提供更详细的进度信息
-			progressDetails := types.ProgressDetails{
-				Current:     completedCount,
-				Total:       total,
-				CurrentItem: fmt.Sprintf("并行下载完成: %s (成功: %d/%d)", result.URL, successCount, completedCount),
-				Phase:       "downloading",
-				Timestamp:   time.Now(),
-			}
-			d.taskUpdater.UpdateTaskProgressWithDetails(progressDetails)
+			currentItem := fmt.Sprintf("并行下载完成: %s (成功: %d/%d)", result.URL, successCount, completed)
+			d.taskUpdater.UpdateTaskProgressWithDetails(d.progressDetails(completed, total, currentItem))
 		}
 	}
 
 	return successCount, nil
 }
+
+// runJob 下载协程池中的单张图片，取消后剩余图片直接返回取消错误
+func (d *Downloader) runJob(pool *workerPool, job *downloadJob, total int, headers map[string]string) DownloadResult {
+	result := DownloadResult{Index: job.index, URL: job.url, FilePath: job.filePath}
+	if d.ctx != nil {
+		if err := d.ctx.Err(); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	busy, size := pool.stats()
+	fmt.Printf("开始下载 [%d/%d]: %s (当前并发: %d/%d)\n", job.index+1, total, job.url, busy, size)
+
+	// 标记为下载中
+	if d.taskUpdater != nil {
+		d.taskUpdater.UpdateItem(types.DownloadItem{
+			URL:      job.url,
+			FilePath: job.filePath,
+			Status:   string(types.StatusDownloading),
+		})
+	}
+
+	// 执行下载
+	savedPath, attempts, err := d.downloadFile(job.url, job.filePath, headers)
+	if err == nil {
+		if info, statErr := os.Stat(savedPath); statErr == nil {
+			result.Bytes = info.Size()
+		}
+	}
+	result.SavedPath = savedPath
+	result.Success = err == nil
+	result.Attempts = attempts
+	result.Error = err
+	return result
+}
@@ -0,0 +1,224 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ImageMaster/core/types"
+)
+
+// testPNG 可完整解码的小图片
+var testPNG = makeTestPNG(4, 4)
+
+// makeTestPNG 生成像素随机的 PNG，尺寸越大文件越大
+func makeTestPNG(width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	rng := rand.New(rand.NewSource(1))
+	rng.Read(img.Pix)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDownloadFileSkipsCompleteFiles(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write(testPNG)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "001.png")
+	d := NewDownloader(Config{RetryCount: 0})
+
+	if err := d.DownloadFile(server.URL, target, nil); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	if _, err := os.Stat(target + PartSuffix); !os.IsNotExist(err) {
+		t.Errorf("temp file should be renamed away, stat err = %v", err)
+	}
+	if err := d.DownloadFile(server.URL, target, nil); err != nil {
+		t.Fatalf("second DownloadFile: %v", err)
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("expected complete file to be skipped, server hit %d times", got)
+	}
+
+	// 不完整的旧文件会被重新下载并替换
+	if err := os.WriteFile(target, []byte("<html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.DownloadFile(server.URL, target, nil); err != nil {
+		t.Fatalf("re-download: %v", err)
+	}
+	if err := ValidateImageFile(target); err != nil {
+		t.Errorf("replaced file should validate: %v", err)
+	}
+
+	// 文件头正确但被截断的旧文件同样重新下载
+	if err := os.WriteFile(target, testPNG[:len(testPNG)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	before := atomic.LoadInt32(&hits)
+	if err := d.DownloadFile(server.URL, target, nil); err != nil {
+		t.Fatalf("re-download truncated: %v", err)
+	}
+	if got := atomic.LoadInt32(&hits); got != before+1 {
+		t.Errorf("truncated file should be re-downloaded, server hit %d times", got-before)
+	}
+	if err := verifyImageFile(target, nil); err != nil {
+		t.Errorf("replaced file should decode: %v", err)
+	}
+}
+
+func TestDownloadFileFailureLeavesNoFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	target := filepath.Join(t.TempDir(), "001.jpg")
+	d := NewDownloader(Config{RetryCount: 0})
+	if err := d.DownloadFile(server.URL, target, nil); err == nil {
+		t.Fatal("expected error for 404")
+	}
+	for _, p := range []string{target, target + PartSuffix} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s should not exist, stat err = %v", p, err)
+		}
+	}
+}
+
+// resumeUpdater 记录续传字节数的任务更新器
+type resumeUpdater struct {
+	types.TaskUpdater
+	resumed int64
+}
+
+func (u *resumeUpdater) AddResumedBytes(n int64) { u.resumed += n }
+
+func TestDownloadFileResumesWithRange(t *testing.T) {
+	content := makeTestPNG(128, 128)
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var requests int32
+	var rangeHeader, ifRange string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// 首次请求只发送一半数据后断开
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:len(content)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		rangeHeader, ifRange = r.Header.Get("Range"), r.Header.Get("If-Range")
+		http.ServeContent(w, r, "img.png", modTime, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	target := filepath.Join(t.TempDir(), "001.png")
+	updater := &resumeUpdater{}
+	d := NewDownloader(Config{RetryCount: 1})
+	d.SetTaskUpdater(updater)
+
+	if err := d.DownloadFile(server.URL, target, nil); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+
+	half := len(content) / 2
+	if rangeHeader != fmt.Sprintf("bytes=%d-", half) || ifRange != `"v1"` {
+		t.Errorf("unexpected resume headers: Range=%q If-Range=%q", rangeHeader, ifRange)
+	}
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("resumed file differs from original (%d vs %d bytes)", len(got), len(content))
+	}
+	if updater.resumed != int64(half) {
+		t.Errorf("resumed bytes = %d, want %d", updater.resumed, half)
+	}
+	if _, err := os.Stat(target + PartSuffix + partMetaSuffix); !os.IsNotExist(err) {
+		t.Errorf("resume metadata should be removed, stat err = %v", err)
+	}
+}
+
+func TestDownloadFileRestartsWhenRangeIgnored(t *testing.T) {
+	content := makeTestPNG(16, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 忽略 Range，总是返回完整内容
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	target := filepath.Join(t.TempDir(), "001.png")
+	partPath := target + PartSuffix
+	state := &partState{URL: server.URL, ETag: `"v1"`}
+	if err := os.WriteFile(partPath, []byte("stale partial data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.save(partPath); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDownloader(Config{RetryCount: 0})
+	if err := d.DownloadFile(server.URL, target, nil); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("expected full download when range is ignored, got %d bytes", len(got))
+	}
+}
+
+func TestProgressMeterETA(t *testing.T) {
+	meter := &progressMeter{}
+	meter.reset()
+	start := meter.lastTime
+
+	// 4 张图片：1 张跳过，2 张各 1000 字节已开始，1 张尚未开始
+	meter.addSkipped()
+	meter.startItem(1000)
+	meter.startItem(1000)
+	meter.addDownloaded(1500)
+	meter.sample(start.Add(time.Second))
+
+	details := types.ProgressDetails{Total: 4}
+	meter.fill(&details)
+	if details.BytesDownloaded != 1500 || details.BytesExpected != 2000 {
+		t.Fatalf("bytes = %d/%d", details.BytesDownloaded, details.BytesExpected)
+	}
+	if details.BytesPerSecond != 1500 {
+		t.Fatalf("speed = %v, want 1500", details.BytesPerSecond)
+	}
+	// 剩余 500 字节加上未开始图片的平均大小 1000 字节
+	if details.ETASeconds != 1 {
+		t.Errorf("eta = %d, want 1", details.ETASeconds)
+	}
+
+	// 中断的图片撤销未接收的部分
+	meter.abortItem(1000, 200)
+	meter.fill(&details)
+	if details.BytesExpected != 1200 {
+		t.Errorf("expected after abort = %d, want 1200", details.BytesExpected)
+	}
+}
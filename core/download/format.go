@@ -0,0 +1,146 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// imageFormats 图片格式的文件头与扩展名，第一个扩展名为首选
+var imageFormats = []struct {
+	offset int
+	magic  []byte
+	exts   []string
+}{
+	{0, []byte{0xFF, 0xD8, 0xFF}, []string{".jpg", ".jpeg", ".jpe", ".jfif"}},
+	{0, []byte{0x89, 'P', 'N', 'G', '\r', '\n'}, []string{".png"}},
+	{0, []byte("GIF87a"), []string{".gif"}},
+	{0, []byte("GIF89a"), []string{".gif"}},
+	{8, []byte("WEBP"), []string{".webp"}},
+	{0, []byte("BM"), []string{".bmp"}},
+	{4, []byte("ftypavif"), []string{".avif"}},
+	{4, []byte("ftypavis"), []string{".avif"}},
+}
+
+// contentTypeExts 无法从文件头识别时按 Content-Type 确定扩展名
+var contentTypeExts = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/jpg":  ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+	"image/avif": ".avif",
+}
+
+// detectImageExts 根据文件头识别图片格式，返回可接受的扩展名；无法识别时按 Content-Type
+func detectImageExts(header []byte, contentType string) []string {
+	for _, format := range imageFormats {
+		end := format.offset + len(format.magic)
+		if len(header) >= end && bytes.Equal(header[format.offset:end], format.magic) {
+			return format.exts
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if ext, ok := contentTypeExts[mediaType]; ok {
+			return []string{ext}
+		}
+	}
+	return nil
+}
+
+// correctImageExt 按实际格式修正文件扩展名，扩展名已匹配或无法识别时原样返回
+func correctImageExt(filePath string, header []byte, contentType string) string {
+	exts := detectImageExts(header, contentType)
+	if len(exts) == 0 {
+		return filePath
+	}
+	ext := filepath.Ext(filePath)
+	for _, accepted := range exts {
+		if strings.EqualFold(ext, accepted) {
+			return filePath
+		}
+	}
+	// 只替换图片扩展名，其他后缀（如无扩展名或 .001）视为文件名的一部分
+	if isImageExt(ext) {
+		filePath = strings.TrimSuffix(filePath, ext)
+	}
+	return filePath + exts[0]
+}
+
+// isImageExt 判断是否为已知的图片扩展名
+func isImageExt(ext string) bool {
+	for _, format := range imageFormats {
+		for _, known := range format.exts {
+			if strings.EqualFold(ext, known) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readHeader 读取文件开头用于识别格式
+func readHeader(filePath string) []byte {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	header := make([]byte, sniffLen)
+	n, _ := io.ReadFull(f, header)
+	return header[:n]
+}
+
+// findExistingImage 查找已下载完成的图片，扩展名可能已按实际格式修正
+// 文件头正确但无法完整解码的文件（如崩溃时留下的截断图片）会被删除并重新下载
+func findExistingImage(filePath string) (string, bool) {
+	candidates := []string{filePath}
+	if ext := filepath.Ext(filePath); isImageExt(ext) {
+		base := strings.TrimSuffix(filePath, ext)
+		seen := map[string]bool{strings.ToLower(ext): true}
+		for _, format := range imageFormats {
+			if ext := format.exts[0]; !seen[ext] {
+				seen[ext] = true
+				candidates = append(candidates, base+ext)
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		if ValidateImageFile(candidate) != nil {
+			continue
+		}
+		if err := verifyImageFile(candidate, nil); err != nil {
+			fmt.Printf("已有文件不完整，删除后重新下载: %s: %v\n", candidate, err)
+			os.Remove(candidate)
+			continue
+		}
+		return candidate, true
+	}
+	return "", false
+}
+
+// RemoveImage 删除图片及其按实际格式修正过扩展名的文件，同时删除未完成的临时文件
+func RemoveImage(filePath string) error {
+	paths := []string{filePath}
+	if ext := filepath.Ext(filePath); isImageExt(ext) {
+		base := strings.TrimSuffix(filePath, ext)
+		for _, format := range imageFormats {
+			if candidate := base + format.exts[0]; candidate != filePath {
+				paths = append(paths, candidate)
+			}
+		}
+	}
+	for _, path := range paths {
+		for _, name := range []string{path, path + PartSuffix, path + PartSuffix + partMetaSuffix} {
+			if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
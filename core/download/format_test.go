@@ -0,0 +1,66 @@
+package download
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCorrectImageExt(t *testing.T) {
+	gifHeader := []byte("GIF89a\x01\x00")
+	jpegHeader := []byte{0xFF, 0xD8, 0xFF, 0xE0}
+	cases := []struct {
+		path, contentType string
+		header            []byte
+		want              string
+	}{
+		{"a/001.jpg", "", testPNG, "a/001.png"},
+		{"a/1_2.jpg", "image/jpeg", gifHeader, "a/1_2.gif"},
+		{"a/001.jpeg", "", jpegHeader, "a/001.jpeg"},
+		{"a/001.JPG", "", jpegHeader, "a/001.JPG"},
+		{"a/001.webp", "", jpegHeader, "a/001.jpg"},
+		{"a/page", "", testPNG, "a/page.png"},
+		{"a/001.jpg", "image/avif", []byte("unknown"), "a/001.avif"},
+		{"a/001.jpg", "", []byte("unknown"), "a/001.jpg"},
+	}
+	for _, c := range cases {
+		if got := correctImageExt(c.path, c.header, c.contentType); got != c.want {
+			t.Errorf("correctImageExt(%q) = %q, want %q", c.path, got, c.want)
+		}
+	}
+}
+
+func TestDownloadFileCorrectsExtension(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write(testPNG)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "001.jpg")
+	d := NewDownloader(Config{RetryCount: 0})
+
+	savedPath, _, err := d.downloadFile(server.URL, target, nil)
+	if err != nil {
+		t.Fatalf("downloadFile: %v", err)
+	}
+	if want := filepath.Join(dir, "001.png"); savedPath != want {
+		t.Fatalf("saved as %q, want %q", savedPath, want)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("%s should not exist", target)
+	}
+
+	// 再次下载时按修正后的文件名识别为已完成
+	if again, _, err := d.downloadFile(server.URL, target, nil); err != nil || again != savedPath {
+		t.Errorf("second download = %q, %v", again, err)
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("server hit %d times, want 1", got)
+	}
+}
@@ -0,0 +1,142 @@
+package download
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// downloadJob 一张待下载的图片
+type downloadJob struct {
+	index    int
+	url      string
+	filePath string
+	priority int // 数值越大越先下载
+	heapIdx  int
+}
+
+// jobHeap 按优先级排列的待下载队列，优先级相同时按列表顺序
+type jobHeap []*downloadJob
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].index < h[j].index
+}
+
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIdx = i
+	h[j].heapIdx = j
+}
+
+func (h *jobHeap) Push(x any) {
+	job := x.(*downloadJob)
+	job.heapIdx = len(*h)
+	*h = append(*h, job)
+}
+
+func (h *jobHeap) Pop() any {
+	old := *h
+	job := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	job.heapIdx = -1
+	return job
+}
+
+// workerPool 固定数量的下载协程，从队列中按优先级取出图片下载
+// 协程数量与图库大小无关，可在下载过程中调整
+type workerPool struct {
+	mu      sync.Mutex
+	queue   jobHeap
+	pending map[string]*downloadJob // 按 URL 索引尚未开始的图片
+	size    int                     // 目标协程数
+	workers int                     // 当前协程数
+	busy    int                     // 正在下载的协程数
+	run     func(job *downloadJob)
+	wg      sync.WaitGroup
+}
+
+// newWorkerPool 创建下载协程池，run 负责下载单张图片并上报结果
+func newWorkerPool(size int, run func(job *downloadJob)) *workerPool {
+	return &workerPool{
+		pending: make(map[string]*downloadJob),
+		size:    max(size, 1),
+		run:     run,
+	}
+}
+
+// start 加入全部图片并启动协程
+func (p *workerPool) start(jobs []*downloadJob) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, job := range jobs {
+		heap.Push(&p.queue, job)
+		p.pending[job.url] = job
+	}
+	p.spawnLocked()
+}
+
+// spawnLocked 补足协程数，队列为空时不再启动新协程
+func (p *workerPool) spawnLocked() {
+	for p.workers < p.size && p.workers < p.busy+p.queue.Len() {
+		p.workers++
+		p.wg.Add(1)
+		go p.work()
+	}
+}
+
+// work 循环取出图片下载，队列为空或协程数超出目标时退出
+func (p *workerPool) work() {
+	defer p.wg.Done()
+	p.mu.Lock()
+	for p.queue.Len() > 0 && p.workers <= p.size {
+		job := heap.Pop(&p.queue).(*downloadJob)
+		delete(p.pending, job.url)
+		p.busy++
+		p.mu.Unlock()
+
+		p.run(job)
+
+		p.mu.Lock()
+		p.busy--
+	}
+	p.workers--
+	p.mu.Unlock()
+}
+
+// resize 调整协程数，减少时正在下载的图片完成后协程退出
+func (p *workerPool) resize(size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.size = max(size, 1)
+	p.spawnLocked()
+}
+
+// setPriority 调整尚未开始的图片的优先级，返回图片是否仍在排队
+func (p *workerPool) setPriority(url string, priority int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	job, ok := p.pending[url]
+	if !ok {
+		return false
+	}
+	job.priority = priority
+	heap.Fix(&p.queue, job.heapIdx)
+	return true
+}
+
+// stats 返回正在下载的数量与目标协程数
+func (p *workerPool) stats() (busy, size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.busy, p.size
+}
+
+// wait 等待全部协程退出
+func (p *workerPool) wait() {
+	p.wg.Wait()
+}
@@ -0,0 +1,126 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func makeJobs(n int) []*downloadJob {
+	jobs := make([]*downloadJob, n)
+	for i := range jobs {
+		jobs[i] = &downloadJob{index: i, url: fmt.Sprintf("u%d", i)}
+	}
+	return jobs
+}
+
+func TestWorkerPoolBoundsConcurrency(t *testing.T) {
+	var running, peak, done atomic.Int32
+	pool := newWorkerPool(4, func(job *downloadJob) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		running.Add(-1)
+		done.Add(1)
+	})
+	pool.start(makeJobs(1000))
+	pool.wait()
+
+	if done.Load() != 1000 {
+		t.Fatalf("completed %d jobs, want 1000", done.Load())
+	}
+	if peak.Load() > 4 {
+		t.Errorf("peak concurrency %d exceeds pool size", peak.Load())
+	}
+}
+
+func TestWorkerPoolPriorityAndResize(t *testing.T) {
+	var mu sync.Mutex
+	var order []int
+	started, release := make(chan struct{}), make(chan struct{})
+	pool := newWorkerPool(1, func(job *downloadJob) {
+		if job.index == 0 {
+			close(started)
+			<-release
+		}
+		mu.Lock()
+		order = append(order, job.index)
+		mu.Unlock()
+	})
+	pool.start(makeJobs(5))
+	<-started
+
+	// 第一张下载中时提高最后一张的优先级
+	if !pool.setPriority("u4", 10) {
+		t.Fatal("u4 should still be pending")
+	}
+	if pool.setPriority("missing", 10) {
+		t.Error("unknown url should not be pending")
+	}
+	close(release)
+	pool.wait()
+
+	want := []int{0, 4, 1, 2, 3}
+	if fmt.Sprint(order) != fmt.Sprint(want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	// 扩容后多个协程同时下载
+	var running, peak atomic.Int32
+	gate := make(chan struct{})
+	pool = newWorkerPool(1, func(job *downloadJob) {
+		if n := running.Add(1); n > peak.Load() {
+			peak.Store(n)
+		}
+		<-gate
+		running.Add(-1)
+	})
+	pool.start(makeJobs(6))
+	pool.resize(3)
+	for running.Load() < 3 {
+	}
+	close(gate)
+	pool.wait()
+	if peak.Load() != 3 {
+		t.Errorf("peak after resize = %d, want 3", peak.Load())
+	}
+}
+
+func TestBatchDownloadStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 2 {
+			cancel()
+		}
+		w.Write(testPNG)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	urls := make([]string, 200)
+	paths := make([]string, 200)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/%d.png", server.URL, i)
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%03d.png", i))
+	}
+
+	d := NewDownloader(Config{Concurrency: 1})
+	d.SetContext(ctx)
+	success, err := d.BatchDownload(urls, paths, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if success > 2 || hits.Load() > 2 {
+		t.Errorf("expected downloads to stop after cancel, got %d successes and %d requests", success, hits.Load())
+	}
+}
@@ -0,0 +1,154 @@
+package download
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"ImageMaster/core/types"
+	"ImageMaster/core/utils"
+)
+
+const (
+	progressInterval = time.Second // 下载中上报字节进度的间隔
+	speedSmoothing   = 0.3         // 速度指数平滑系数，越大越接近瞬时速度
+)
+
+// progressMeter 统计一次批量下载的字节数与平滑后的速度
+type progressMeter struct {
+	mu         sync.Mutex
+	downloaded int64   // 已接收的字节数
+	expected   int64   // 已开始下载的图片的总字节数
+	sized      int     // 已知大小的图片数
+	skipped    int     // 已存在而跳过的图片数
+	speed      float64 // 平滑后的速度（字节/秒）
+	lastBytes  int64
+	lastTime   time.Time
+}
+
+// reset 开始新的批量下载
+func (m *progressMeter) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.downloaded, m.expected, m.lastBytes = 0, 0, 0
+	m.sized, m.skipped = 0, 0
+	m.speed = 0
+	m.lastTime = time.Now()
+}
+
+// addDownloaded 累加接收的字节数
+func (m *progressMeter) addDownloaded(n int64) {
+	m.mu.Lock()
+	m.downloaded += n
+	m.mu.Unlock()
+}
+
+// startItem 开始接收一张图片，size 为本次响应的字节数，未知时为负数
+func (m *progressMeter) startItem(size int64) {
+	m.mu.Lock()
+	if size >= 0 {
+		m.expected += size
+		m.sized++
+	}
+	m.mu.Unlock()
+}
+
+// abortItem 图片接收中断，撤销未接收的部分，重试时重新统计
+func (m *progressMeter) abortItem(size, received int64) {
+	m.mu.Lock()
+	if size >= 0 {
+		m.expected -= max(size-received, 0)
+		m.sized--
+	}
+	m.mu.Unlock()
+}
+
+// addSkipped 记录一张已存在而跳过的图片
+func (m *progressMeter) addSkipped() {
+	m.mu.Lock()
+	m.skipped++
+	m.mu.Unlock()
+}
+
+// sample 按距上次采样的字节数更新平滑速度
+func (m *progressMeter) sample(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elapsed := now.Sub(m.lastTime).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	current := float64(m.downloaded-m.lastBytes) / elapsed
+	if m.lastBytes == 0 && m.speed == 0 {
+		m.speed = current
+	} else {
+		m.speed = speedSmoothing*current + (1-speedSmoothing)*m.speed
+	}
+	m.lastBytes = m.downloaded
+	m.lastTime = now
+}
+
+// fill 将字节数、速度与预计剩余时间填入进度信息
+// 尚未开始的图片按已知图片的平均大小估算
+func (m *progressMeter) fill(details *types.ProgressDetails) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	details.BytesDownloaded = m.downloaded
+	details.BytesExpected = m.expected
+	details.BytesPerSecond = m.speed
+	details.Speed = utils.FormatSpeed(m.speed)
+
+	remaining := max(m.expected-m.downloaded, 0)
+	if unknown := details.Total - m.sized - m.skipped; unknown > 0 && m.sized > 0 {
+		remaining += m.expected / int64(m.sized) * int64(unknown)
+	}
+	if m.speed > 0 {
+		details.ETASeconds = int64(float64(remaining)/m.speed + 0.5)
+		details.ETA = utils.FormatETA(details.ETASeconds)
+	}
+}
+
+// countingReader 读取时累加字节数
+type countingReader struct {
+	r     io.Reader
+	meter *progressMeter
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		c.meter.addDownloaded(int64(n))
+	}
+	return n, err
+}
+
+// progressDetails 生成带字节进度的详细进度信息
+func (d *Downloader) progressDetails(current, total int, currentItem string) types.ProgressDetails {
+	details := types.ProgressDetails{
+		Current:     current,
+		Total:       total,
+		CurrentItem: currentItem,
+		Phase:       string(types.StatusDownloading),
+		Timestamp:   time.Now(),
+	}
+	d.meter.fill(&details)
+	return details
+}
+
+// reportProgress 定期采样速度并上报进度，直到 done 关闭
+func (d *Downloader) reportProgress(done <-chan struct{}, completed func() int, total int) {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			d.meter.sample(now)
+			if d.taskUpdater != nil {
+				d.taskUpdater.UpdateTaskProgressWithDetails(d.progressDetails(completed(), total, ""))
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"ImageMaster/core/request"
+	"ImageMaster/core/utils"
+)
+
+// partMetaSuffix 临时文件续传信息的后缀，如 001.jpg.part.meta
+const partMetaSuffix = ".meta"
+
+// partState 临时文件的续传信息
+// 只有记录了 ETag 或 Last-Modified 的临时文件才能安全续传
+type partState struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	ContentType  string `json:"contentType,omitempty"` // 用于确定扩展名
+
+	offset int64 // 临时文件中已有的字节数
+}
+
+// loadPartState 读取上次遗留的临时文件，无法续传时删除
+func loadPartState(partPath, url string) *partState {
+	state := &partState{URL: url}
+
+	info, err := os.Stat(partPath)
+	if err != nil {
+		return state
+	}
+
+	var saved partState
+	data, err := os.ReadFile(partPath + partMetaSuffix)
+	if err != nil || json.Unmarshal(data, &saved) != nil || saved.URL != url || saved.ifRange() == "" || info.Size() == 0 {
+		removePart(partPath)
+		return state
+	}
+	saved.offset = info.Size()
+	return &saved
+}
+
+// ifRange 返回 If-Range 请求头的值，弱 ETag 不能用于范围请求
+func (s *partState) ifRange() string {
+	if s.ETag != "" && !strings.HasPrefix(s.ETag, "W/") {
+		return s.ETag
+	}
+	return s.LastModified
+}
+
+// reset 丢弃已下载的数据，下次从头开始
+func (s *partState) reset(partPath string) {
+	removePart(partPath)
+	s.ETag = ""
+	s.LastModified = ""
+	s.ContentType = ""
+	s.offset = 0
+}
+
+// save 保存续传信息
+func (s *partState) save(partPath string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(partPath+partMetaSuffix, data, 0644)
+}
+
+// removePart 删除临时文件及其续传信息
+func removePart(partPath string) {
+	os.Remove(partPath)
+	os.Remove(partPath + partMetaSuffix)
+}
+
+// fetchToPart 执行一次下载，已有部分数据时发送 Range 请求续传
+// 返回通过续传省下的字节数
+func (d *Downloader) fetchToPart(url, partPath string, state *partState, headers map[string]string) (int64, error) {
+	opts := request.Options{Headers: headers}
+	resuming := state.offset > 0 && state.ifRange() != ""
+	if resuming {
+		opts.Range = &request.ByteRange{Start: state.offset, End: -1}
+		opts.IfRange = state.ifRange()
+	}
+
+	resp, err := d.reqClient.GetWithOptions(url, opts)
+	if err != nil {
+		return 0, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 流量超限等错误页常以 200 返回，写入前先按类型排除
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusPartialContent {
+		if err := checkContentType(resp.Header.Get("Content-Type")); err != nil {
+			return 0, err
+		}
+	}
+
+	var flag int
+	var resumed int64
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if !resuming {
+			return 0, newStatusError(resp)
+		}
+		// 范围或资源标识不一致时丢弃已有数据
+		if err := checkContentRange(resp.Header.Get("Content-Range"), state.offset); err != nil {
+			state.reset(partPath)
+			return 0, err
+		}
+		if etag := resp.Header.Get("ETag"); etag != "" && state.ETag != "" && etag != state.ETag {
+			state.reset(partPath)
+			return 0, fmt.Errorf("ETag 不一致，重新下载")
+		}
+		flag = os.O_WRONLY | os.O_APPEND
+		resumed = state.offset
+	case http.StatusOK:
+		// 首次下载，或服务器忽略了 Range / 资源已变化，从头写入
+		state.offset = 0
+		state.ContentType = resp.Header.Get("Content-Type")
+		state.ETag = resp.Header.Get("ETag")
+		state.LastModified = resp.Header.Get("Last-Modified")
+		if resp.Header.Get("Accept-Ranges") == "none" {
+			state.ETag, state.LastModified = "", ""
+		}
+		if state.ifRange() != "" {
+			if err := state.save(partPath); err != nil {
+				return 0, fmt.Errorf("保存续传信息失败: %w", err)
+			}
+		} else {
+			os.Remove(partPath + partMetaSuffix)
+		}
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		state.reset(partPath)
+		return 0, newStatusError(resp)
+	default:
+		return 0, newStatusError(resp)
+	}
+
+	// 统计接收的字节数，中断时撤销未接收的部分
+	size := resp.ContentLength
+	d.meter.startItem(size)
+	n, err := writePartFile(partPath, flag, &countingReader{r: utils.Bandwidth.Reader(d.ctx, resp.Body), meter: d.meter})
+	state.offset += n
+	if err != nil {
+		d.meter.abortItem(size, n)
+		return 0, err
+	}
+	// 连接提前关闭时数据可能不完整，已收到的部分保留用于续传
+	if size >= 0 && n != size {
+		d.meter.abortItem(size, n)
+		return 0, fmt.Errorf("数据不完整: 收到 %d 字节，应为 %d 字节", n, size)
+	}
+	return resumed, nil
+}
+
+// checkContentRange 校验 206 响应的 Content-Range 是否从 offset 开始
+func checkContentRange(contentRange string, offset int64) error {
+	// 形如 "bytes 100-999/1000"
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return fmt.Errorf("无效的 Content-Range: %q", contentRange)
+	}
+	startText, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return fmt.Errorf("无效的 Content-Range: %q", contentRange)
+	}
+	start, err := strconv.ParseInt(strings.TrimSpace(startText), 10, 64)
+	if err != nil || start != offset {
+		return fmt.Errorf("Content-Range 与已下载位置不一致: %q", contentRange)
+	}
+	return nil
+}
+
+// writePartFile 将数据写入临时文件并落盘，返回写入的字节数
+// 写入中断时保留已写入的数据以便续传
+func writePartFile(partPath string, flag int, body io.Reader) (int64, error) {
+	out, err := os.OpenFile(partPath, flag, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("创建文件失败: %w", err)
+	}
+
+	n, copyErr := io.Copy(out, body)
+	syncErr := out.Sync()
+	closeErr := out.Close()
+	switch {
+	case copyErr != nil:
+		return n, fmt.Errorf("数据写入失败: %w", copyErr)
+	case syncErr != nil:
+		return n, fmt.Errorf("文件同步失败: %w", syncErr)
+	case closeErr != nil:
+		return n, fmt.Errorf("关闭文件失败: %w", closeErr)
+	}
+	return n, nil
+}
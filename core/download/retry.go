@@ -0,0 +1,124 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ImageMaster/core/types"
+)
+
+// maxRetryWait 单次重试等待的绝对上限，避免 Retry-After 过大导致任务长时间挂起
+const maxRetryWait = 5 * time.Minute
+
+// ErrPlaceholderImage 下载到的是配置中的占位图，重试也不会得到真实图片
+var ErrPlaceholderImage = errors.New("下载到的是占位图")
+
+// statusError 非预期的 HTTP 状态码
+type statusError struct {
+	code       int
+	retryAfter time.Duration // 服务器通过 Retry-After 要求的等待时间
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("状态码错误: %d", e.code)
+}
+
+// newStatusError 根据响应生成状态码错误
+func newStatusError(resp *http.Response) error {
+	return &statusError{
+		code:       resp.StatusCode,
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter 解析 Retry-After，支持秒数与 HTTP 日期两种格式
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// isPermanentError 判断错误是否为永久性错误（重试无意义）
+// 404、410 等客户端错误与占位图为永久性错误；5xx、429、超时、连接重置、数据不完整等视为暂时性错误
+func isPermanentError(err error) bool {
+	if errors.Is(err, ErrPlaceholderImage) {
+		return true
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		switch se.code {
+		case http.StatusRequestTimeout, http.StatusRequestedRangeNotSatisfiable,
+			http.StatusTooEarly, http.StatusTooManyRequests:
+			return false
+		}
+		return se.code >= 400 && se.code < 500
+	}
+	return false
+}
+
+// retryWait 计算第 attempt 次重试前的等待时间：指数退避加随机抖动，服务器要求更长时按 Retry-After
+func retryWait(policy types.RetryPolicy, attempt int, err error) time.Duration {
+	limit := time.Duration(policy.MaxDelayMs) * time.Millisecond
+	if limit <= 0 || limit > maxRetryWait {
+		limit = maxRetryWait
+	}
+
+	wait := min(time.Duration(policy.BaseDelayMs)*time.Millisecond, limit)
+	for i := 1; i < attempt && wait < limit; i++ {
+		wait = min(wait*2, limit)
+	}
+	if jitter := min(policy.Jitter, 1); jitter > 0 && wait > 0 {
+		wait += time.Duration(rand.Float64() * jitter * float64(wait))
+	}
+
+	var se *statusError
+	if errors.As(err, &se) && se.retryAfter > wait {
+		wait = min(se.retryAfter, maxRetryWait)
+	}
+	return max(wait, 0)
+}
+
+// sleepContext 等待指定时间，上下文取消时立即返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	if ctx == nil {
+		time.Sleep(d)
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryPolicy 获取当前站点的重试策略，未配置时按下载器自身的重试次数与间隔
+func (d *Downloader) retryPolicy() types.RetryPolicy {
+	if provider, ok := d.configManager.(types.RetryConfigProvider); ok {
+		return provider.GetRetryConfig().PolicyFor(d.siteType)
+	}
+	return types.RetryPolicy{
+		MaxRetries:  d.retryCount,
+		BaseDelayMs: int(d.retryDelay / time.Millisecond),
+		MaxDelayMs:  int(maxRetryWait / time.Millisecond),
+	}
+}
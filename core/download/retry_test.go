@@ -0,0 +1,108 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ImageMaster/core/types"
+)
+
+func TestIsPermanentError(t *testing.T) {
+	cases := map[error]bool{
+		&statusError{code: 404}:                     true,
+		&statusError{code: 410}:                     true,
+		&statusError{code: 429}:                     false,
+		&statusError{code: 503}:                     false,
+		fmt.Errorf("下载失败: %w", ErrPlaceholderImage): true,
+		errors.New("connection reset by peer"):      false,
+	}
+	for err, want := range cases {
+		if got := isPermanentError(err); got != want {
+			t.Errorf("isPermanentError(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	policy := types.RetryPolicy{BaseDelayMs: 1000, MaxDelayMs: 5000}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := retryWait(policy, i+1, nil); got != w {
+			t.Errorf("attempt %d: wait = %v, want %v", i+1, got, w)
+		}
+	}
+
+	policy.Jitter = 0.5
+	if got := retryWait(policy, 1, nil); got < time.Second || got >= 1500*time.Millisecond {
+		t.Errorf("jittered wait = %v, want within [1s, 1.5s)", got)
+	}
+
+	// Retry-After 更长时以服务器要求为准
+	err := &statusError{code: 429, retryAfter: 30 * time.Second}
+	if got := retryWait(policy, 1, err); got != 30*time.Second {
+		t.Errorf("Retry-After wait = %v, want 30s", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := parseRetryAfter("120", now); got != 2*time.Minute {
+		t.Errorf("seconds: got %v", got)
+	}
+	date := now.Add(90 * time.Second).Format(http.TimeFormat)
+	if got := parseRetryAfter(date, now); got != 90*time.Second {
+		t.Errorf("http date: got %v", got)
+	}
+	if got := parseRetryAfter("soon", now); got != 0 {
+		t.Errorf("invalid: got %v", got)
+	}
+}
+
+func TestDownloadFileDoesNotRetryNotFound(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	d := NewDownloader(Config{RetryCount: 3})
+	if err := d.DownloadFile(server.URL, filepath.Join(t.TempDir(), "001.png"), nil); err == nil {
+		t.Fatal("expected error for 404")
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("404 should not be retried, got %d requests", got)
+	}
+}
+
+func TestDownloadFileHonorsRetryAfter(t *testing.T) {
+	var requests int32
+	var first time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if elapsed := time.Since(first); elapsed < time.Second {
+			t.Errorf("retried after %v, before Retry-After", elapsed)
+		}
+		w.Write(testPNG)
+	}))
+	defer server.Close()
+
+	d := NewDownloader(Config{RetryCount: 1})
+	if err := d.DownloadFile(server.URL, filepath.Join(t.TempDir(), "001.png"), nil); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+}
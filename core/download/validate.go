@@ -0,0 +1,156 @@
+package download
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"os"
+	"strings"
+
+	"ImageMaster/core/types"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+)
+
+// PartSuffix 下载中的临时文件后缀，下载完成后重命名为最终文件
+const PartSuffix = ".part"
+
+// sniffLen 识别文件类型时读取的字节数
+const sniffLen = 16
+
+// isImageHeader 判断文件头是否为已知图片格式
+func isImageHeader(header []byte) bool {
+	return len(detectImageExts(header, "")) > 0
+}
+
+// ValidateImageFile 校验已存在的图片文件：非空且文件头为已知图片格式
+func ValidateImageFile(filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("不是普通文件")
+	}
+	if info.Size() == 0 {
+		return fmt.Errorf("文件为空")
+	}
+
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return fmt.Errorf("读取文件头失败: %w", err)
+	}
+	if !isImageHeader(header[:n]) {
+		return fmt.Errorf("无法识别的图片格式")
+	}
+	return nil
+}
+
+// checkContentType 拒绝明显不是图片的响应，如流量超限时返回的 HTML 页面
+// 缺失或通用的类型（如 application/octet-stream）交由文件内容校验
+func checkContentType(contentType string) error {
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		mediaType == "application/xml",
+		mediaType == "application/xhtml+xml":
+		return fmt.Errorf("响应不是图片: %s", mediaType)
+	}
+	return nil
+}
+
+// looksLikeHTML 判断内容是否为网页
+func looksLikeHTML(data []byte) bool {
+	head := bytes.ToLower(bytes.TrimSpace(data[:min(len(data), 512)]))
+	return bytes.HasPrefix(head, []byte("<!doctype html")) ||
+		bytes.HasPrefix(head, []byte("<html")) ||
+		bytes.HasPrefix(head, []byte("<head")) ||
+		bytes.HasPrefix(head, []byte("<body"))
+}
+
+// verifyImageData 校验下载完成的图片：文件头、完整解码与占位图黑名单
+// 校验失败的文件应丢弃并重新下载
+func verifyImageData(data []byte, placeholders map[string]bool) error {
+	if len(data) == 0 {
+		return fmt.Errorf("文件为空")
+	}
+	if looksLikeHTML(data) {
+		return fmt.Errorf("服务器返回了网页而不是图片")
+	}
+	if !isImageHeader(data[:min(len(data), sniffLen)]) {
+		return fmt.Errorf("无法识别的图片格式")
+	}
+
+	if len(placeholders) > 0 {
+		sum := sha256.Sum256(data)
+		if placeholders[hex.EncodeToString(sum[:])] {
+			return ErrPlaceholderImage
+		}
+	}
+
+	// 完整解码以发现截断或损坏的文件，没有解码器的格式（如 AVIF）只校验文件头
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err == image.ErrFormat {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("图片解码失败: %w", err)
+	}
+	if format == "gif" {
+		_, err = gif.DecodeAll(bytes.NewReader(data))
+	} else {
+		_, _, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return fmt.Errorf("图片不完整或已损坏: %w", err)
+	}
+	return nil
+}
+
+// verifyImageFile 读取并校验下载完成的图片文件
+func verifyImageFile(filePath string, placeholders map[string]bool) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	return verifyImageData(data, placeholders)
+}
+
+// placeholderHashes 从配置中读取占位图哈希
+func (d *Downloader) placeholderHashes() map[string]bool {
+	provider, ok := d.configManager.(types.ValidationConfigProvider)
+	if !ok {
+		return nil
+	}
+	hashes := provider.GetValidationConfig().PlaceholderHashes
+	if len(hashes) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		set[strings.ToLower(strings.TrimSpace(hash))] = true
+	}
+	return set
+}
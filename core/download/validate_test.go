@@ -0,0 +1,112 @@
+package download
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"ImageMaster/core/types"
+)
+
+// placeholderConfig 只提供占位图哈希的配置
+type placeholderConfig struct {
+	hashes []string
+}
+
+func (c *placeholderConfig) GetOutputDir() string { return "" }
+func (c *placeholderConfig) GetProxy() string     { return "" }
+func (c *placeholderConfig) GetValidationConfig() types.ValidationConfig {
+	return types.ValidationConfig{PlaceholderHashes: c.hashes}
+}
+
+func TestVerifyImageData(t *testing.T) {
+	var jpg, gifData bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	if err := jpeg.Encode(&jpg, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.Encode(&gifData, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := map[string][]byte{"png": testPNG, "jpeg": jpg.Bytes(), "gif": gifData.Bytes()}
+	for name, data := range valid {
+		if err := verifyImageData(data, nil); err != nil {
+			t.Errorf("%s should be valid: %v", name, err)
+		}
+	}
+
+	invalid := map[string][]byte{
+		"empty":     nil,
+		"html":      []byte("  <!DOCTYPE html><html><body>509 Bandwidth Exceeded</body></html>"),
+		"text":      []byte("Your IP address has been temporarily banned"),
+		"truncated": jpg.Bytes()[:jpg.Len()/2],
+	}
+	for name, data := range invalid {
+		if err := verifyImageData(data, nil); err == nil {
+			t.Errorf("%s should be rejected", name)
+		}
+	}
+
+	sum := sha256.Sum256(testPNG)
+	if err := verifyImageData(testPNG, map[string]bool{hex.EncodeToString(sum[:]): true}); err == nil {
+		t.Errorf("placeholder image should be rejected")
+	}
+}
+
+func TestCheckContentType(t *testing.T) {
+	for _, ct := range []string{"", "image/webp", "application/octet-stream", "binary/octet-stream"} {
+		if err := checkContentType(ct); err != nil {
+			t.Errorf("%q should be accepted: %v", ct, err)
+		}
+	}
+	for _, ct := range []string{"text/html; charset=utf-8", "application/json"} {
+		if err := checkContentType(ct); err == nil {
+			t.Errorf("%q should be rejected", ct)
+		}
+	}
+}
+
+func TestDownloadFileRejectsPlaceholder(t *testing.T) {
+	placeholder := makeTestPNG(2, 2)
+	sum := sha256.Sum256(placeholder)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&requests, 1) {
+		case 1:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html>509</html>"))
+		case 2:
+			w.Write(placeholder)
+		default:
+			w.Write(testPNG)
+		}
+	}))
+	defer server.Close()
+
+	target := filepath.Join(t.TempDir(), "001.png")
+	d := NewDownloader(Config{RetryCount: 2})
+	d.SetConfigManager(&placeholderConfig{hashes: []string{hex.EncodeToString(sum[:])}})
+	// 错误页会重试，占位图为永久性错误，不再继续请求
+	err := d.DownloadFile(server.URL, target, nil)
+	if !errors.Is(err, ErrPlaceholderImage) {
+		t.Fatalf("expected placeholder error, got %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("placeholder should not be saved, stat err = %v", err)
+	}
+}
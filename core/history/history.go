@@ -44,7 +44,7 @@ func NewHistoryManager(appName string) *HistoryManager {
 	return m
 }
 
-// AddRecord 添加下载记录
+// AddRecord 添加下载记录，相同 ID 的记录原地更新
 func (m *HistoryManager) AddRecord(d *dto.DownloadTaskDTO) {
 	if d == nil {
 		logger.Warn("Invalid task for download history: nil")
@@ -54,8 +54,18 @@ func (m *HistoryManager) AddRecord(d *dto.DownloadTaskDTO) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// 添加到历史记录
-	m.downloadHistory = append(m.downloadHistory, d)
+	// 已存在则替换，否则追加
+	replaced := false
+	for i, record := range m.downloadHistory {
+		if record.ID == d.ID {
+			m.downloadHistory[i] = d
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		m.downloadHistory = append(m.downloadHistory, d)
+	}
 	logger.Debug("Added download record: %s", d.Name)
 
 	// 保存历史记录
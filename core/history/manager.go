@@ -8,6 +8,7 @@ import (
 
 // 确保 Manager 实现 types.HistoryStore 接口
 var _ types.HistoryStore = (*Manager)(nil)
+var _ types.DataDirProvider = (*Manager)(nil)
 
 // Manager 历史存储管理器
 type Manager struct {
@@ -30,6 +31,11 @@ func (m *Manager) GetDownloadHistory() []*dto.DownloadTaskDTO {
 	return m.historyManager.GetHistory()
 }
 
+// GetDataDir 获取数据目录
+func (m *Manager) GetDataDir() string {
+	return m.historyManager.dataDir
+}
+
 // ClearDownloadHistory 清除下载历史
 func (m *Manager) ClearDownloadHistory() {
 	m.historyManager.ClearHistory()
@@ -50,10 +50,11 @@ func defaultLogDir() string {
 
 // 确保 std 存在（即使未显式 Init）
 func ensure() {
-	if std != nil {
-		return
-	}
 	once.Do(func() {
+		// 已通过 Init 配置时保留
+		if std != nil {
+			return
+		}
 		levelVar.Set(slog.LevelInfo)
 		h := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: levelVar})
 		std = slog.New(h).With("app", "ImageMaster")
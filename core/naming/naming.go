@@ -0,0 +1,308 @@
+package naming
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ImageMaster/core/utils"
+)
+
+// 默认模板，与未配置时的保存路径一致
+const (
+	DefaultFolderTemplate = "{name}"
+	DefaultFileTemplate   = "{index:03}{ext}"
+)
+
+// MaxSegmentBytes 目录名或文件名的最大字节数，超出时截断（常见文件系统上限为 255）
+const MaxSegmentBytes = 200
+
+// Gallery 画廊级模板变量的来源
+type Gallery struct {
+	URL           string
+	Site          string // 站点类型
+	Name          string // 画廊名称（可被开始下载时的选项覆盖）
+	Title         string
+	OriginalTitle string
+	Artists       []string
+	Groups        []string
+	Parodies      []string
+	Language      string
+	Category      string
+	Pages         int       // 画廊总页数
+	UploadDate    time.Time // 上传时间
+	Date          time.Time // 下载时间
+}
+
+// Page 图片级模板变量的来源
+type Page struct {
+	Index int    // 原始页码，从 1 开始
+	Name  string // 解析器提供的文件名，不含扩展名
+	Ext   string // 扩展名，含点
+}
+
+// galleryVars 画廊级变量，目录与文件模板都可使用
+var galleryVars = map[string]bool{
+	"site": true, "host": true, "id": true, "slug": true,
+	"name": true, "title": true, "original_title": true,
+	"artist": true, "artists": true, "group": true, "parody": true,
+	"language": true, "category": true, "pages": true,
+	"date": true, "year": true, "month": true, "day": true, "upload_date": true,
+}
+
+// pageVars 图片级变量，只能用于文件模板
+var pageVars = map[string]bool{"index": true, "filename": true, "ext": true}
+
+// part 模板的一段：文本或变量
+type part struct {
+	text string
+	name string // 变量名，为空表示文本
+	spec string // 格式：0N 补零到 N 位，N 截断到 N 个字符
+}
+
+// Template 解析后的命名模板，如 {site}/{artist}/[{id}] {title}
+// {{ 与 }} 表示字面量花括号
+type Template struct {
+	parts []part
+}
+
+// Parse 解析模板，file 为 false 时不允许使用图片级变量
+func Parse(text string, file bool) (*Template, error) {
+	t := &Template{}
+	var literal strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '{' && strings.HasPrefix(text[i:], "{{"), c == '}' && strings.HasPrefix(text[i:], "}}"):
+			literal.WriteByte(c)
+			i++
+		case c == '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("模板缺少 }: %s", text)
+			}
+			name, spec, _ := strings.Cut(text[i+1:i+end], ":")
+			name = strings.TrimSpace(name)
+			if !galleryVars[name] && !pageVars[name] {
+				return nil, fmt.Errorf("未知的模板变量: {%s}", name)
+			}
+			if pageVars[name] && !file {
+				return nil, fmt.Errorf("目录模板不能使用图片变量: {%s}", name)
+			}
+			if spec != "" {
+				if _, err := strconv.Atoi(spec); err != nil {
+					return nil, fmt.Errorf("无效的变量格式: {%s:%s}", name, spec)
+				}
+			}
+			if literal.Len() > 0 {
+				t.parts = append(t.parts, part{text: literal.String()})
+				literal.Reset()
+			}
+			t.parts = append(t.parts, part{name: name, spec: spec})
+			i += end
+		case c == '}':
+			return nil, fmt.Errorf("模板中多余的 }: %s", text)
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	if literal.Len() > 0 {
+		t.parts = append(t.parts, part{text: literal.String()})
+	}
+	return t, nil
+}
+
+// render 代入变量，变量值中的路径分隔符会被替换，不会产生额外的目录层级
+func (t *Template) render(vars map[string]string) string {
+	var b strings.Builder
+	for _, p := range t.parts {
+		if p.name == "" {
+			b.WriteString(p.text)
+			continue
+		}
+		value := strings.NewReplacer("/", "_", "\\", "_").Replace(vars[p.name])
+		b.WriteString(formatValue(value, p.spec))
+	}
+	return b.String()
+}
+
+// formatValue 按格式处理变量值：0N 对数字补零，N 截断字符数
+func formatValue(value, spec string) string {
+	if spec == "" {
+		return value
+	}
+	width, _ := strconv.Atoi(spec)
+	if strings.HasPrefix(spec, "0") {
+		if n, err := strconv.Atoi(value); err == nil {
+			return fmt.Sprintf("%0*d", width, n)
+		}
+		return value
+	}
+	if width > 0 && utf8.RuneCountInString(value) > width {
+		return strings.TrimSpace(string([]rune(value)[:width]))
+	}
+	return value
+}
+
+// RenderFolder 按目录模板生成画廊目录（相对路径，可包含多级目录）
+func RenderFolder(text string, g Gallery) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultFolderTemplate
+	}
+	t, err := Parse(text, false)
+	if err != nil {
+		return "", err
+	}
+	folder := cleanPath(t.render(g.vars()), false)
+	if folder == "" {
+		folder = cleanPath(g.Name, false)
+	}
+	if folder == "" {
+		folder = "download"
+	}
+	return folder, nil
+}
+
+// RenderFile 按文件模板生成图片文件名（相对画廊目录，可包含子目录）
+func RenderFile(text string, g Gallery, p Page) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultFileTemplate
+	}
+	t, err := Parse(text, true)
+	if err != nil {
+		return "", err
+	}
+	vars := g.vars()
+	vars["index"] = strconv.Itoa(p.Index)
+	vars["filename"] = p.Name
+	vars["ext"] = p.Ext
+	file := cleanPath(t.render(vars), true)
+	if strings.TrimSuffix(path.Base(file), p.Ext) == "" || file == "" {
+		file = fmt.Sprintf("%03d%s", p.Index, p.Ext)
+	}
+	return file, nil
+}
+
+// vars 画廊级变量的值
+func (g Gallery) vars() map[string]string {
+	title := g.Title
+	if title == "" {
+		title = g.Name
+	}
+	originalTitle := g.OriginalTitle
+	if originalTitle == "" {
+		originalTitle = title
+	}
+	date := g.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	vars := map[string]string{
+		"site":           g.Site,
+		"name":           g.Name,
+		"title":          title,
+		"original_title": originalTitle,
+		"artist":         first(g.Artists),
+		"artists":        strings.Join(g.Artists, ", "),
+		"group":          first(g.Groups),
+		"parody":         first(g.Parodies),
+		"language":       g.Language,
+		"category":       g.Category,
+		"date":           date.Format("2006-01-02"),
+		"year":           date.Format("2006"),
+		"month":          date.Format("01"),
+		"day":            date.Format("02"),
+	}
+	if g.Pages > 0 {
+		vars["pages"] = strconv.Itoa(g.Pages)
+	}
+	if !g.UploadDate.IsZero() {
+		vars["upload_date"] = g.UploadDate.Format("2006-01-02")
+	}
+	if u, err := url.Parse(g.URL); err == nil {
+		vars["host"] = u.Hostname()
+		vars["id"] = urlID(u.Path)
+		vars["slug"] = path.Base(strings.TrimSuffix(u.Path, "/"))
+		if vars["slug"] == "." || vars["slug"] == "/" {
+			vars["slug"] = ""
+		}
+	}
+	return vars
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+var digitsPattern = regexp.MustCompile(`\d+`)
+
+// urlID 从 URL 路径中提取画廊 ID：优先取纯数字的路径段，否则取最后一串数字
+// 如 /g/123456/abcdef/ 为 123456，/photos-index-aid-98765.html 为 98765
+func urlID(urlPath string) string {
+	for _, segment := range strings.Split(urlPath, "/") {
+		if segment != "" && strings.Trim(segment, "0123456789") == "" {
+			return segment
+		}
+	}
+	if matches := digitsPattern.FindAllString(urlPath, -1); len(matches) > 0 {
+		return matches[len(matches)-1]
+	}
+	return ""
+}
+
+var (
+	emptyBrackets = regexp.MustCompile(`\[\s*\]|\(\s*\)|【\s*】|\{\s*\}`)
+	spaces        = regexp.MustCompile(`\s{2,}`)
+)
+
+// CleanName 将插件、脚本等外部来源给出的名称规范化为单个路径段
+// 路径分隔符替换为 _，清理后为空（如 . 与 ..）时返回空字符串
+func CleanName(name string, file bool) string {
+	return cleanPath(strings.NewReplacer("/", "_", "\\", "_").Replace(name), file)
+}
+
+// cleanPath 规范化渲染结果：替换非法字符、去掉变量为空留下的空括号与多余空格、限制每段长度
+// file 为 true 时截断最后一段会保留扩展名
+func cleanPath(rendered string, file bool) string {
+	// 以 / 开头按绝对路径规范化，避免 "A: xxx" 这样的名称被当作盘符
+	rendered = "/" + strings.ReplaceAll(rendered, "\\", "/")
+	segments := strings.Split(utils.NormalizePath(rendered), "/")
+	cleaned := segments[:0]
+	for i, segment := range segments {
+		segment = emptyBrackets.ReplaceAllString(segment, "")
+		segment = spaces.ReplaceAllString(segment, " ")
+		// Windows 不允许名称以空格或点结尾，同时排除 . 与 ..
+		segment = strings.TrimLeft(strings.TrimRight(segment, " ."), " ")
+		if segment == "" {
+			continue
+		}
+		keepExt := file && i == len(segments)-1
+		cleaned = append(cleaned, truncateSegment(segment, keepExt))
+	}
+	return strings.Join(cleaned, "/")
+}
+
+// truncateSegment 将名称截断到 MaxSegmentBytes 字节以内，不截断多字节字符
+func truncateSegment(segment string, keepExt bool) string {
+	if len(segment) <= MaxSegmentBytes {
+		return segment
+	}
+	ext := ""
+	if keepExt {
+		ext = path.Ext(segment)
+		segment = strings.TrimSuffix(segment, ext)
+	}
+	limit := MaxSegmentBytes - len(ext)
+	for limit > 0 && !utf8.RuneStart(segment[limit]) {
+		limit--
+	}
+	return strings.TrimRight(segment[:limit], " .") + ext
+}
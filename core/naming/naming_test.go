@@ -0,0 +1,106 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var sample = Gallery{
+	URL:        "https://e-hentai.org/g/123456/abcdef/",
+	Site:       "ehentai",
+	Name:       "Sample Gallery",
+	Title:      "Sample: Title",
+	Artists:    []string{"Artist A/B", "Artist C"},
+	Pages:      24,
+	UploadDate: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+	Date:       time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+}
+
+func TestRenderFolder(t *testing.T) {
+	cases := map[string]string{
+		"":                                  "Sample Gallery",
+		"{site}/{artist}/[{id}] {title}":    "ehentai/Artist A_B/[123456] Sample_ Title",
+		"{year}-{month}/{title:6}":          "2025-01/Sample",
+		"{host}/[{language}] {name}":        "e-hentai.org/Sample Gallery",
+		"{{{id}}} {upload_date} ({pages}p)": "{123456} 2024-03-05 (24p)",
+		"../{category}/./{name}":            "Sample Gallery",
+	}
+	for tmpl, want := range cases {
+		got, err := RenderFolder(tmpl, sample)
+		if err != nil {
+			t.Errorf("RenderFolder(%q): %v", tmpl, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("RenderFolder(%q) = %q, want %q", tmpl, got, want)
+		}
+	}
+
+	for _, tmpl := range []string{"{unknown}", "{title", "{index}", "{title:x}", "a}b"} {
+		if _, err := RenderFolder(tmpl, sample); err == nil {
+			t.Errorf("RenderFolder(%q) should fail", tmpl)
+		}
+	}
+}
+
+func TestRenderFile(t *testing.T) {
+	page := Page{Index: 7, Name: "img_0007", Ext: ".png"}
+	cases := map[string]string{
+		"":                          "007.png",
+		"{index:04}{ext}":           "0007.png",
+		"{filename}{ext}":           "img_0007.png",
+		"{id}_{index}-{pages}{ext}": "123456_7-24.png",
+		"{category}{ext}":           "007.png",
+	}
+	for tmpl, want := range cases {
+		got, err := RenderFile(tmpl, sample, page)
+		if err != nil {
+			t.Errorf("RenderFile(%q): %v", tmpl, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("RenderFile(%q) = %q, want %q", tmpl, got, want)
+		}
+	}
+
+	// 超长名称按字节截断且保留扩展名
+	long := sample
+	long.Title = strings.Repeat("标题", 100)
+	got, err := RenderFile("{title}{ext}", long, page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) > MaxSegmentBytes || !strings.HasSuffix(got, ".png") || !strings.HasPrefix(got, "标题") {
+		t.Errorf("truncated name = %q (%d bytes)", got, len(got))
+	}
+}
+
+func TestURLID(t *testing.T) {
+	cases := map[string]string{
+		"/g/123456/abcdef/":                  "123456",
+		"/photos-index-aid-98765.html":       "98765",
+		"/doujinshi/title-english-4567.html": "4567",
+		"/post/abc":                          "",
+	}
+	for p, want := range cases {
+		if got := urlID(p); got != want {
+			t.Errorf("urlID(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestCleanName(t *testing.T) {
+	cases := map[string]string{
+		"..":            "",
+		".":             "",
+		"../../etc":     ".._.._etc",
+		`a\b\c`:         "a_b_c",
+		"Gallery  []  ": "Gallery",
+	}
+	for name, want := range cases {
+		if got := CleanName(name, false); got != want {
+			t.Errorf("CleanName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
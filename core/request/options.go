@@ -0,0 +1,84 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Options 单次请求的选项，只作用于本次请求，不修改客户端的共享状态，可并发使用
+type Options struct {
+	Context     context.Context   // 请求上下文，为空时使用客户端的默认上下文
+	Headers     map[string]string // 附加请求头，覆盖客户端的同名请求头
+	Referer     string
+	Cookies     []*http.Cookie // 附加 Cookie
+	Timeout     time.Duration  // 本次请求的超时，0 表示使用客户端默认超时
+	Range       *ByteRange     // 只请求部分数据
+	IfRange     string         // 与 Range 一起发送的 If-Range，资源变化时服务器返回完整内容
+	ContentType string         // 期望的响应类型前缀（如 "image/"），响应类型不符时返回错误；未返回类型时不检查
+}
+
+// ByteRange 请求的字节范围，End 小于 0 表示到末尾
+type ByteRange struct {
+	Start int64
+	End   int64
+}
+
+// header 转换为 Range 请求头
+func (r ByteRange) header() string {
+	if r.End < 0 {
+		return fmt.Sprintf("bytes=%d-", r.Start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", r.Start, r.End)
+}
+
+// apply 将选项应用到请求
+func (o *Options) apply(req *http.Request) {
+	for key, value := range o.Headers {
+		req.Header.Set(key, value)
+	}
+	if o.Referer != "" {
+		req.Header.Set("Referer", o.Referer)
+	}
+	for _, cookie := range o.Cookies {
+		req.AddCookie(cookie)
+	}
+	if o.Range != nil {
+		req.Header.Set("Range", o.Range.header())
+		if o.IfRange != "" {
+			req.Header.Set("If-Range", o.IfRange)
+		}
+	}
+}
+
+// checkContentType 检查响应类型是否符合预期
+func (o *Options) checkContentType(resp *http.Response) error {
+	contentType := resp.Header.Get("Content-Type")
+	if o.ContentType == "" || contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	if !strings.HasPrefix(strings.ToLower(mediaType), strings.ToLower(o.ContentType)) {
+		return fmt.Errorf("响应类型不符: %s，应为 %s", mediaType, o.ContentType)
+	}
+	return nil
+}
+
+// cancelOnClose 关闭响应体时释放单次请求的超时上下文
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
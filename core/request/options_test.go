@@ -0,0 +1,91 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"ImageMaster/core/types"
+)
+
+func TestDoWithOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		cookie, _ := r.Cookie("sid")
+		value := ""
+		if cookie != nil {
+			value = cookie.Value
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Echo", r.Header.Get("X-Test")+"|"+r.Referer()+"|"+value+"|"+r.Header.Get("Range")+"|"+r.Header.Get("If-Range"))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	resp, err := client.GetWithOptions(server.URL, Options{
+		Headers: map[string]string{"X-Test": "1"},
+		Referer: "https://example.com/",
+		Cookies: []*http.Cookie{{Name: "sid", Value: "abc"}},
+		Range:   &ByteRange{Start: 100, End: -1},
+		IfRange: `"v1"`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Echo"); got != `1|https://example.com/|abc|bytes=100-|"v1"` {
+		t.Errorf("request options not applied: %q", got)
+	}
+
+	// 选项不会残留在客户端上
+	resp, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Echo"); got != "||||" {
+		t.Errorf("options leaked into later request: %q", got)
+	}
+
+	if _, err := client.GetWithOptions(server.URL, Options{ContentType: "image/"}); err == nil {
+		t.Error("expected content type mismatch error")
+	}
+	if _, err := client.GetWithOptions(server.URL+"/slow", Options{Timeout: 50 * time.Millisecond}); err == nil {
+		t.Error("expected per-request timeout")
+	}
+}
+
+func TestDoWithOptionsConcurrent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Echo", r.Header.Get("X-Id"))
+	}))
+	defer server.Close()
+
+	// 只验证并发安全，不限速
+	RegisterRateLimit("unlimited-test", types.RateLimitPolicy{})
+	SetSiteResolver(func(string) string { return "unlimited-test" })
+	defer SetSiteResolver(nil)
+
+	client := NewClient()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			resp, err := client.GetWithOptions(server.URL, Options{Headers: map[string]string{"X-Id": id}})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if got := resp.Header.Get("X-Echo"); got != id {
+				t.Errorf("request %s got header %q", id, got)
+			}
+		}(string(rune('a' + i)))
+	}
+	wg.Wait()
+}
@@ -0,0 +1,146 @@
+package request
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"ImageMaster/core/types"
+)
+
+// DefaultRateLimit 未注册默认值的站点使用的速率限制
+var DefaultRateLimit = types.RateLimitPolicy{RequestsPerSecond: 5, Burst: 10}
+
+var (
+	rateLimitMu sync.Mutex
+	// hostLimiters 按 host 共享的令牌桶，进程内所有任务与客户端共用
+	hostLimiters = map[string]*tokenBucket{}
+	// siteRateLimits 各站点类型的内置默认速率
+	siteRateLimits = map[string]types.RateLimitPolicy{}
+	// siteResolver 根据 host 识别站点类型，由爬虫注册表设置
+	siteResolver func(host string) string
+)
+
+// RegisterRateLimit 注册站点类型的内置默认速率，可被配置覆盖
+func RegisterRateLimit(siteType string, policy types.RateLimitPolicy) {
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+	siteRateLimits[siteType] = policy
+}
+
+// SetSiteResolver 设置根据 host 识别站点类型的函数
+func SetSiteResolver(resolver func(host string) string) {
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+	siteResolver = resolver
+}
+
+// tokenBucket 令牌桶，令牌可以透支，透支部分按速率排队等待
+type tokenBucket struct {
+	mu     sync.Mutex
+	policy types.RateLimitPolicy
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket 创建装满令牌的令牌桶
+func newTokenBucket(policy types.RateLimitPolicy, now time.Time) *tokenBucket {
+	b := &tokenBucket{policy: policy, last: now}
+	b.tokens = b.burst()
+	return b
+}
+
+// burst 突发请求数，至少为 1
+func (b *tokenBucket) burst() float64 {
+	return float64(max(b.policy.Burst, 1))
+}
+
+// reserve 取走一个令牌，返回需要等待的时间；速率变化时按新速率计算
+func (b *tokenBucket) reserve(policy types.RateLimitPolicy, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if policy != b.policy {
+		b.policy = policy
+		b.tokens = min(b.tokens, b.burst())
+	}
+	rate := b.policy.RequestsPerSecond
+	if rate <= 0 {
+		return 0
+	}
+
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed*rate, b.burst())
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// cancel 归还未使用的令牌
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	b.tokens = min(b.tokens+1, b.burst())
+	b.mu.Unlock()
+}
+
+// rateLimitFor 获取 host 对应的令牌桶与速率：配置优先，其次为站点内置默认值
+func (c *Client) rateLimitFor(host string) (*tokenBucket, types.RateLimitPolicy) {
+	rateLimitMu.Lock()
+	resolver := siteResolver
+	rateLimitMu.Unlock()
+
+	siteType := ""
+	if resolver != nil {
+		siteType = resolver(host)
+	}
+
+	// 读取配置时不持有全局锁，避免配置读取阻塞其他 host 的请求
+	var policy types.RateLimitPolicy
+	custom := false
+	if provider, ok := c.configManager.(types.RateLimitConfigProvider); ok {
+		policy, custom = provider.GetRateLimitConfig().Sites[siteType]
+	}
+
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+	if !custom {
+		var ok bool
+		if policy, ok = siteRateLimits[siteType]; !ok {
+			policy = DefaultRateLimit
+		}
+	}
+
+	bucket, ok := hostLimiters[host]
+	if !ok {
+		bucket = newTokenBucket(policy, time.Now())
+		hostLimiters[host] = bucket
+	}
+	return bucket, policy
+}
+
+// waitRateLimit 等待目标 host 的请求令牌，上下文取消时立即返回
+func (c *Client) waitRateLimit(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return nil
+	}
+	bucket, policy := c.rateLimitFor(strings.ToLower(parsed.Hostname()))
+	wait := bucket.reserve(policy, time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		bucket.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,117 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ImageMaster/core/types"
+)
+
+func TestTokenBucketReserve(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := types.RateLimitPolicy{RequestsPerSecond: 2, Burst: 2}
+	b := newTokenBucket(policy, now)
+
+	// 突发额度内无需等待，超出后按速率排队
+	for i := 0; i < 2; i++ {
+		if wait := b.reserve(policy, now); wait != 0 {
+			t.Fatalf("request %d: wait = %v, want 0", i, wait)
+		}
+	}
+	if wait := b.reserve(policy, now); wait != 500*time.Millisecond {
+		t.Errorf("third request: wait = %v, want 500ms", wait)
+	}
+	if wait := b.reserve(policy, now); wait != time.Second {
+		t.Errorf("fourth request: wait = %v, want 1s", wait)
+	}
+
+	// 令牌按时间补充，且不超过突发数
+	later := now.Add(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		if wait := b.reserve(policy, later); wait != 0 {
+			t.Fatalf("after refill %d: wait = %v, want 0", i, wait)
+		}
+	}
+	if wait := b.reserve(policy, later); wait == 0 {
+		t.Error("tokens should be capped at burst")
+	}
+
+	// 不限速
+	if wait := b.reserve(types.RateLimitPolicy{}, later); wait != 0 {
+		t.Errorf("unlimited: wait = %v, want 0", wait)
+	}
+}
+
+// rateLimitConfig 只提供速率限制配置
+type rateLimitConfig struct {
+	types.ConfigProvider
+	sites map[string]types.RateLimitPolicy
+}
+
+func (c *rateLimitConfig) GetRateLimitConfig() types.RateLimitConfig {
+	return types.RateLimitConfig{Sites: c.sites}
+}
+
+func TestRateLimitForSharesBucketsPerHost(t *testing.T) {
+	RegisterRateLimit("test-site", types.RateLimitPolicy{RequestsPerSecond: 1, Burst: 1})
+	SetSiteResolver(func(host string) string {
+		if host == "limited.test" {
+			return "test-site"
+		}
+		return "generic"
+	})
+	defer SetSiteResolver(nil)
+
+	a, b := NewClient(), NewClient()
+	bucketA, policy := a.rateLimitFor("limited.test")
+	bucketB, _ := b.rateLimitFor("limited.test")
+	if bucketA != bucketB {
+		t.Error("clients should share the per-host bucket")
+	}
+	if policy.RequestsPerSecond != 1 {
+		t.Errorf("built-in default not applied: %+v", policy)
+	}
+	if _, policy := a.rateLimitFor("other.test"); policy != DefaultRateLimit {
+		t.Errorf("unregistered site should use default, got %+v", policy)
+	}
+
+	// 配置覆盖内置默认值
+	b.configManager = &rateLimitConfig{sites: map[string]types.RateLimitPolicy{"test-site": {RequestsPerSecond: 10, Burst: 5}}}
+	if _, policy := b.rateLimitFor("limited.test"); policy.RequestsPerSecond != 10 {
+		t.Errorf("config override not applied: %+v", policy)
+	}
+
+	// 等待令牌时可被取消
+	ctx, cancel := context.WithCancel(context.Background())
+	a.waitRateLimit(ctx, "http://limited.test/1")
+	cancel()
+	if err := a.waitRateLimit(ctx, "http://limited.test/2"); err != context.Canceled {
+		t.Errorf("waitRateLimit after cancel = %v, want context.Canceled", err)
+	}
+}
+
+func TestPlainRequestsAreRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	RegisterRateLimit("plain-site", types.RateLimitPolicy{RequestsPerSecond: 20, Burst: 1})
+	SetSiteResolver(func(string) string { return "plain-site" })
+	defer SetSiteResolver(nil)
+
+	// 图片下载等不经过 RateLimitedGet 的请求同样受令牌桶限制
+	client := NewClient()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.GetWithOptions(server.URL, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests at 20/s with burst 1 took %v, want >= 100ms", elapsed)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"golang.org/x/net/http2"
@@ -15,10 +16,12 @@ import (
 )
 
 // Client HTTP客户端封装
+// 客户端级的请求头与 Cookie 应在初始化时设置，单次请求的差异通过 Options 传入
 type Client struct {
 	client         *http.Client
 	proxyManager   *ProxyManager
 	configManager  types.ConfigProvider
+	mu             sync.RWMutex // 保护 headers 与 cookies
 	headers        map[string]string
 	cookies        []*http.Cookie
 	defaultHeaders map[string]string
@@ -101,25 +104,42 @@ func (c *Client) GetProxy() string {
 	return c.proxyManager.GetProxy()
 }
 
-// SetHeader 设置请求头
+// SetHeader 设置客户端级请求头，用于初始化；单次请求的请求头请使用 Options
 func (c *Client) SetHeader(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.headers[key] = value
 }
 
-// SetHeaders 批量设置请求头
+// SetHeaders 批量设置客户端级请求头，用于初始化；单次请求的请求头请使用 Options
 func (c *Client) SetHeaders(headers map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	for key, value := range headers {
 		c.headers[key] = value
 	}
 }
 
-// AddCookie 添加Cookie
+// AddCookie 添加客户端级Cookie，用于初始化
 func (c *Client) AddCookie(cookie *http.Cookie) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.cookies = append(c.cookies, cookie)
 }
 
+// Cookies 获取客户端当前附加的Cookie副本
+func (c *Client) Cookies() []*http.Cookie {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cookies := make([]*http.Cookie, len(c.cookies))
+	copy(cookies, c.cookies)
+	return cookies
+}
+
 // ClearCookies 清除所有Cookie
 func (c *Client) ClearCookies() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.cookies = make([]*http.Cookie, 0)
 }
 
@@ -156,50 +176,48 @@ func (c *Client) PostWithContext(ctx context.Context, url string, body io.Reader
 
 // DoRequest 执行HTTP请求
 func (c *Client) DoRequest(method, url string, body io.Reader, extraHeaders map[string]string) (*http.Response, error) {
-	// 尝试从配置中应用代理（如果尚未设置代理且配置管理器存在）
-	if c.proxyManager == nil && c.configManager != nil {
-		c.proxyManager = NewProxyManager(c.configManager)
-		// c.proxyManager.ApplyToClient(c.client)
-	}
+	return c.Do(method, url, body, Options{Headers: extraHeaders})
+}
 
-	// 创建请求
-	var req *http.Request
-	var err error
-	if c.ctx != nil {
-		req, err = http.NewRequestWithContext(c.ctx, method, url, body)
-	} else {
-		req, err = http.NewRequest(method, url, body)
-	}
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
+// DoRequestWithContext 执行带上下文的HTTP请求
+func (c *Client) DoRequestWithContext(ctx context.Context, method, url string, body io.Reader, extraHeaders map[string]string) (*http.Response, error) {
+	return c.Do(method, url, body, Options{Context: ctx, Headers: extraHeaders})
+}
 
-	// 设置默认头部
-	for key, value := range c.defaultHeaders {
-		req.Header.Set(key, value)
-	}
+// GetWithOptions 发送带单次请求选项的GET请求
+func (c *Client) GetWithOptions(url string, opts Options) (*http.Response, error) {
+	return c.Do("GET", url, nil, opts)
+}
 
-	// 应用客户端的通用头部
-	for key, value := range c.headers {
-		req.Header.Set(key, value)
+// Do 按单次请求选项执行HTTP请求，不修改客户端状态，可并发调用
+// 所有请求都按目标 host 的令牌桶限速，与其他任务和客户端共享速率
+func (c *Client) Do(method, url string, body io.Reader, opts Options) (*http.Response, error) {
+	ctx := c.requestContext(opts)
+	if err := c.waitRateLimit(ctx, url); err != nil {
+		return nil, err
 	}
+	return c.send(ctx, method, url, body, opts)
+}
 
-	// 应用额外的请求头
-	for key, value := range extraHeaders {
-		req.Header.Set(key, value)
+// send 发送请求并处理单次请求超时，调用方已完成限速等待
+func (c *Client) send(ctx context.Context, method, url string, body io.Reader, opts Options) (*http.Response, error) {
+	if opts.Timeout <= 0 {
+		return c.do(ctx, method, url, body, opts)
 	}
 
-	// 应用Cookie
-	for _, cookie := range c.cookies {
-		req.AddCookie(cookie)
+	// 单次请求超时在响应体关闭时释放
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	resp, err := c.do(ctx, method, url, body, opts)
+	if err != nil {
+		cancel()
+		return nil, err
 	}
-
-	// 执行请求
-	return c.client.Do(req)
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
 }
 
-// DoRequestWithContext 执行带上下文的HTTP请求
-func (c *Client) DoRequestWithContext(ctx context.Context, method, url string, body io.Reader, extraHeaders map[string]string) (*http.Response, error) {
+// do 创建并发送请求
+func (c *Client) do(ctx context.Context, method, url string, body io.Reader, opts Options) (*http.Response, error) {
 	// 尝试从配置中应用代理（如果尚未设置代理且配置管理器存在）
 	if c.proxyManager == nil && c.configManager != nil {
 		c.proxyManager = NewProxyManager(c.configManager)
@@ -217,23 +235,40 @@ func (c *Client) DoRequestWithContext(ctx context.Context, method, url string, b
 		req.Header.Set(key, value)
 	}
 
-	// 应用客户端的通用头部
+	// 应用客户端的通用头部与Cookie
+	c.mu.RLock()
 	for key, value := range c.headers {
 		req.Header.Set(key, value)
 	}
-
-	// 应用额外的请求头
-	for key, value := range extraHeaders {
-		req.Header.Set(key, value)
-	}
-
-	// 应用Cookie
 	for _, cookie := range c.cookies {
 		req.AddCookie(cookie)
 	}
+	c.mu.RUnlock()
+
+	// 应用本次请求的选项
+	opts.apply(req)
 
 	// 执行请求
-	return c.client.Do(req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.checkContentType(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// requestContext 获取请求上下文：选项中的上下文优先，其次为客户端默认上下文
+func (c *Client) requestContext(opts Options) context.Context {
+	if opts.Context != nil {
+		return opts.Context
+	}
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
 }
 
 // GetHTTPClient 获取底层HTTP客户端
@@ -241,15 +276,20 @@ func (c *Client) GetHTTPClient() *http.Client {
 	return c.client
 }
 
-// RateLimitedGet 使用默认速率信号量的GET请求（替代原token_bucket功能）
+// RateLimitedGet 受限速的GET请求：先按 host 的令牌桶控制速率，再按信号量控制并发
 func (c *Client) RateLimitedGet(url string) (*http.Response, error) {
-	if c.ctx != nil {
-		if err := c.semaphore.AcquireWithContext(c.ctx); err != nil {
-			return nil, err
-		}
-	} else {
-		c.semaphore.Acquire()
+	return c.RateLimitedGetWithOptions(url, Options{})
+}
+
+// RateLimitedGetWithOptions 带单次请求选项的受限速GET请求
+func (c *Client) RateLimitedGetWithOptions(url string, opts Options) (*http.Response, error) {
+	ctx := c.requestContext(opts)
+	if err := c.waitRateLimit(ctx, url); err != nil {
+		return nil, err
+	}
+	if err := c.semaphore.AcquireWithContext(ctx); err != nil {
+		return nil, err
 	}
 	defer c.semaphore.Release()
-	return c.Get(url)
+	return c.send(ctx, "GET", url, nil, opts)
 }
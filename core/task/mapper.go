@@ -17,6 +17,14 @@ func ToDownloadTaskDTO(t *DownloadTask) *dto.DownloadTaskDTO {
 		UpdatedAt:    t.UpdatedAt,
 		Error:        t.Error,
 		Name:         t.Name,
+		Collision:    t.Collision,
+		GalleryPath:  t.GalleryPath,
+
+		Phase:           t.Phase,
+		BytesDownloaded: t.BytesDownloaded,
+		BytesExpected:   t.BytesExpected,
+		Speed:           t.Speed,
+		ETA:             t.ETA,
 	}
 	d.Progress.Current = t.Progress.Current
 	d.Progress.Total = t.Progress.Total
@@ -0,0 +1,170 @@
+package task
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"ImageMaster/core/types"
+)
+
+// newBusyTaskManager 创建并发名额已占满的任务管理器，新任务只会排队
+func newBusyTaskManager(dir string) *TaskManager {
+	tm := NewTaskManager(Config{}, nil)
+	tm.SetStateDir(dir)
+	for _, id := range []string{"busy1", "busy2", "busy3"} {
+		tm.running[id] = id
+	}
+	return tm
+}
+
+func TestPauseResumeSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	tm := newBusyTaskManager(dir)
+
+	task, err := tm.AddTaskWithOptions("https://example.com/gallery/1", StartOptions{Pages: "1-2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task.setItems([]types.DownloadItem{
+		{PageNumber: 1, URL: "https://example.com/1.jpg", FilePath: "a/001.jpg", Status: string(types.StatusCompleted)},
+		{PageNumber: 2, URL: "https://example.com/2.jpg", FilePath: "a/002.jpg", Status: string(types.StatusPending)},
+	})
+	if task.QueuePosition != 1 {
+		t.Fatalf("position = %d, want 1", task.QueuePosition)
+	}
+
+	if err := tm.PauseTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != string(types.StatusPaused) || len(tm.queue) != 0 {
+		t.Fatalf("status = %s, queue = %d", task.Status, len(tm.queue))
+	}
+
+	// 重启后恢复为暂停状态，保留选项与图片列表
+	restarted := newBusyTaskManager(dir)
+	restored := restarted.GetTaskByID(task.ID)
+	if restored == nil {
+		t.Fatalf("task not restored")
+	}
+	if restored.Status != string(types.StatusPaused) || !restarted.activeTasks[task.ID] {
+		t.Errorf("restored status = %s", restored.Status)
+	}
+	if restored.options.Pages != "1-2" || len(restored.unfinishedItems()) != 1 {
+		t.Errorf("restored options = %+v, items = %+v", restored.options, restored.items)
+	}
+
+	if err := restarted.ResumeTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Status != string(types.StatusPending) || restored.QueuePosition != 1 {
+		t.Errorf("resumed status = %s, position = %d", restored.Status, restored.QueuePosition)
+	}
+	if err := restarted.ResumeTask(task.ID); err == nil {
+		t.Errorf("resuming a pending task should fail")
+	}
+
+	// 取消后不再恢复
+	if !restarted.CancelTask(task.ID) {
+		t.Fatalf("cancel failed")
+	}
+	if again := newBusyTaskManager(dir); again.GetTaskByID(task.ID) != nil {
+		t.Errorf("cancelled task should not be restored")
+	}
+}
+
+func TestInterruptedTasksRequeuedOnRestart(t *testing.T) {
+	dir := t.TempDir()
+	tm := newBusyTaskManager(dir)
+
+	first, _ := tm.AddTaskWithOptions("https://example.com/gallery/1", StartOptions{})
+	second, _ := tm.AddTaskWithOptions("https://example.com/gallery/2", StartOptions{})
+	failed, _ := tm.AddTaskWithOptions("https://example.com/gallery/3", StartOptions{})
+	tm.CancelTask(failed.ID)
+	tm.mu.Lock()
+	failed.Status = string(types.StatusFailed)
+	failed.setItems([]types.DownloadItem{
+		{PageNumber: 1, FilePath: "c/001.jpg", Status: string(types.StatusFailed)},
+	})
+	tm.mu.Unlock()
+	tm.saveTaskStates()
+
+	restarted := newBusyTaskManager(dir)
+	if len(restarted.queue) != 2 {
+		t.Fatalf("queue = %d, want 2", len(restarted.queue))
+	}
+	if restarted.queue[0].taskID != first.ID || restarted.queue[1].taskID != second.ID {
+		t.Errorf("queue order not preserved")
+	}
+	if got := restarted.GetTaskByID(second.ID); got.Status != string(types.StatusPending) || got.QueuePosition != 2 {
+		t.Errorf("second = %s at %d", got.Status, got.QueuePosition)
+	}
+
+	// 失败的任务保留记录，不自动执行
+	got := restarted.GetTaskByID(failed.ID)
+	if got == nil || got.Status != string(types.StatusFailed) || got.FailedCount != 1 {
+		t.Fatalf("failed task not restored: %+v", got)
+	}
+	if restarted.activeTasks[failed.ID] {
+		t.Errorf("failed task should not be active")
+	}
+}
+
+func TestCancelWhilePausing(t *testing.T) {
+	tm := NewTaskManager(Config{}, nil)
+	task := &DownloadTask{ID: "t1", Status: string(types.StatusDownloading)}
+	tm.tasks[task.ID] = task
+	tm.activeTasks[task.ID] = true
+	tm.taskCancelMap[task.ID] = make(chan struct{})
+
+	// 进行中的任务请求暂停后，在停止前仍可取消
+	if err := tm.PauseTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !tm.CancelTask(task.ID) {
+		t.Fatal("CancelTask should succeed while the task is pausing")
+	}
+	if task.Status != string(types.StatusCancelled) || tm.pausing[task.ID] {
+		t.Errorf("status = %s, pausing = %v", task.Status, tm.pausing[task.ID])
+	}
+}
+
+func TestResumeImmediatelyAfterPause(t *testing.T) {
+	tm := newBusyTaskManager(t.TempDir())
+	task := &DownloadTask{ID: "t1", URL: "https://example.com/gallery/1", Status: string(types.StatusPending)}
+	tm.tasks[task.ID] = task
+	tm.activeTasks[task.ID] = true
+	cancelChan := make(chan struct{})
+	tm.taskCancelMap[task.ID] = cancelChan
+	tm.running[task.ID] = task.SiteType
+
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tm.runTask(task.ID, cancelChan, types.StatusDownloading, func(ctx context.Context, _ *DownloadTask, _ types.ImageCrawler) (string, error) {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+	}()
+	<-started
+
+	if err := tm.PauseTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	// 停止前不能继续，停止后立即继续；继续后的任务不受上一轮清理影响
+	for tm.ResumeTask(task.ID) != nil {
+		runtime.Gosched()
+	}
+	<-done
+
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	if _, ok := tm.taskCancelMap[task.ID]; !ok || !tm.activeTasks[task.ID] {
+		t.Errorf("resumed task lost its cancel channel or active flag")
+	}
+	if task.Status != string(types.StatusPending) || task.QueuePosition != 1 {
+		t.Errorf("status = %s, position = %d", task.Status, task.QueuePosition)
+	}
+}
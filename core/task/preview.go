@@ -0,0 +1,257 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ImageMaster/core/crawler/parsers"
+	"ImageMaster/core/types"
+	"ImageMaster/core/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	previewTTL        = 30 * time.Minute // 预览结果缓存时长
+	previewThumbCount = 4                // 预览返回的缩略图数量
+)
+
+// CrawlPreview 仅解析不下载的预览结果
+type CrawlPreview struct {
+	PreviewID  string                   `json:"previewId"`  // 预览ID，用于 StartFromPreview
+	URL        string                   `json:"url"`        // 原始URL
+	SiteType   string                   `json:"siteType"`   // 识别出的站点类型
+	Name       string                   `json:"name"`       // 画廊名称
+	PageCount  int                      `json:"pageCount"`  // 图片数量
+	Thumbnails []string                 `json:"thumbnails"` // 前几张图片地址
+	Metadata   *parsers.GalleryMetadata `json:"metadata"`   // 画廊元数据
+	SavePath   string                   `json:"savePath"`   // 按命名模板生成的第一张图片的保存路径
+	ExpiresAt  time.Time                `json:"expiresAt"`  // 缓存过期时间
+
+	GalleryPath string                `json:"galleryPath"` // 将要使用的画廊目录
+	Exists      bool                  `json:"exists"`      // 画廊目录是否已存在
+	Collision   types.CollisionPolicy `json:"collision"`   // 目录已存在时将采用的处理方式
+	Warning     string                `json:"warning"`     // 目录已存在时的提示，开始下载前展示
+}
+
+// StartOptions 开始下载时的选项
+type StartOptions struct {
+	Name     string `json:"name"`     // 覆盖画廊名称（即保存目录名），为空时使用解析结果
+	Pages    string `json:"pages"`    // 页码选择，如 "1-20,45,60-"，为空表示全部
+	PageList []int  `json:"pageList"` // 明确的页码列表，与 Pages 取并集
+	Priority int    `json:"priority"` // 任务优先级，数值越大越先执行
+
+	Collision types.CollisionPolicy `json:"collision"` // 画廊目录已存在时的处理方式，为空时使用配置
+}
+
+// PageSelection 合并 Pages 与 PageList 得到页码选择，nil 表示全部
+func (o StartOptions) PageSelection() (*utils.PageSelection, error) {
+	spec := strings.TrimSpace(o.Pages)
+	for _, page := range o.PageList {
+		if page < 1 {
+			return nil, fmt.Errorf("无效的页码 %d: 页码从 1 开始", page)
+		}
+		if spec != "" {
+			spec += ","
+		}
+		spec += strconv.Itoa(page)
+	}
+	return utils.ParsePageSelection(spec)
+}
+
+// validate 检查选项是否有效，在创建任务或消耗预览前调用
+func (o StartOptions) validate() error {
+	if _, err := o.PageSelection(); err != nil {
+		return err
+	}
+	if o.Collision != "" && !o.Collision.Valid() {
+		return fmt.Errorf("未知的目录冲突处理方式: %s", o.Collision)
+	}
+	return nil
+}
+
+// previewEntry 缓存的预览解析结果
+type previewEntry struct {
+	url       string
+	result    *parsers.ParseResult
+	expiresAt time.Time
+}
+
+// previewCache 预览结果缓存，过期项在访问时清理
+type previewCache struct {
+	entries map[string]*previewEntry
+	mu      sync.Mutex
+}
+
+func newPreviewCache() *previewCache {
+	return &previewCache{entries: make(map[string]*previewEntry)}
+}
+
+// put 缓存解析结果并返回预览ID
+func (c *previewCache) put(url string, result *parsers.ParseResult) (string, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.purgeLocked()
+
+	id := uuid.New().String()
+	expiresAt := time.Now().Add(previewTTL)
+	c.entries[id] = &previewEntry{url: url, result: result, expiresAt: expiresAt}
+	return id, expiresAt
+}
+
+// take 取出并移除缓存项，每个预览只能开始一次下载
+func (c *previewCache) take(id string) (*previewEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.purgeLocked()
+
+	entry, ok := c.entries[id]
+	if ok {
+		delete(c.entries, id)
+	}
+	return entry, ok
+}
+
+func (c *previewCache) purgeLocked() {
+	now := time.Now()
+	for id, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+}
+
+// PreviewCrawl 仅解析URL，不下载；解析结果缓存在预览ID下
+// 目录冲突的处理方式按 options.Collision 判断，为空时使用配置
+func (tm *TaskManager) PreviewCrawl(url string, options StartOptions) (*CrawlPreview, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	siteType, result, err := tm.parseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	savePath, galleryPath, action, err := tm.sampleSavePath(siteType, result, options)
+	if err != nil {
+		return nil, err
+	}
+
+	previewID, expiresAt := tm.previews.put(url, result)
+	thumbnails := result.ImageURLs
+	if len(thumbnails) > previewThumbCount {
+		thumbnails = thumbnails[:previewThumbCount]
+	}
+
+	return &CrawlPreview{
+		PreviewID:  previewID,
+		URL:        url,
+		SiteType:   siteType,
+		Name:       result.Name,
+		PageCount:  len(result.ImageURLs),
+		Thumbnails: append([]string(nil), thumbnails...),
+		Metadata:   result.Metadata,
+		SavePath:   savePath,
+		ExpiresAt:  expiresAt,
+
+		GalleryPath: galleryPath,
+		Exists:      action != "",
+		Collision:   action,
+		Warning:     collisionWarning(action, galleryPath),
+	}, nil
+}
+
+// PreviewSavePath 解析URL并按当前命名模板生成第一张图片的保存路径，不缓存也不下载
+func (tm *TaskManager) PreviewSavePath(url string, options StartOptions) (string, error) {
+	if err := options.validate(); err != nil {
+		return "", err
+	}
+	siteType, result, err := tm.parseURL(url)
+	if err != nil {
+		return "", err
+	}
+	savePath, _, _, err := tm.sampleSavePath(siteType, result, options)
+	return savePath, err
+}
+
+// sampleSavePath 按站点的命名模板与目录冲突处理方式生成第一张图片的保存路径
+// 同时返回画廊目录，以及目录已存在时将采用的处理方式（不存在时为空）
+func (tm *TaskManager) sampleSavePath(siteType string, result *parsers.ParseResult, options StartOptions) (string, string, types.CollisionPolicy, error) {
+	contentPath, filePaths, err := result.SavePaths(tm.outputDir(), siteType, tm.namingTemplates(siteType))
+	if err != nil {
+		return "", "", "", err
+	}
+	action, galleryPath, err := parsers.ResolveCollision(tm.collisionPolicy(options), contentPath)
+	if err != nil {
+		return "", "", "", err
+	}
+	savePath := galleryPath
+	if len(filePaths) > 0 {
+		savePath = galleryPath + strings.TrimPrefix(filePaths[0], contentPath)
+	}
+	return utils.NormalizePath(savePath), utils.NormalizePath(galleryPath), action, nil
+}
+
+// collisionWarning 画廊目录已存在时的提示
+func collisionWarning(action types.CollisionPolicy, galleryPath string) string {
+	switch action {
+	case types.CollisionMerge:
+		return "画廊目录已存在，将只下载缺失的图片"
+	case types.CollisionSkip:
+		return "画廊目录已存在，将跳过下载"
+	case types.CollisionOverwrite:
+		return "画廊目录已存在，将重新下载并覆盖已有图片"
+	case types.CollisionRename:
+		return fmt.Sprintf("画廊目录已存在，将下载到新目录: %s", galleryPath)
+	}
+	return ""
+}
+
+// parseURL 识别站点类型并解析URL
+func (tm *TaskManager) parseURL(url string) (string, *parsers.ParseResult, error) {
+	ctx := tm.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	crawlerFactory := tm.newCrawlerFactory(ctx)
+	siteType := crawlerFactory.DetectSiteType(url)
+	crawlerInstance, err := crawlerFactory.Create(url)
+	if err != nil {
+		return "", nil, err
+	}
+	parsed, ok := crawlerInstance.(parsers.ParsedCrawler)
+	if !ok {
+		return "", nil, fmt.Errorf("站点类型 %s 不支持预览", siteType)
+	}
+	if withCtx, ok := crawlerInstance.(interface{ SetContext(context.Context) }); ok {
+		withCtx.SetContext(ctx)
+	}
+
+	result, err := parsed.Parse(url)
+	if err != nil {
+		return "", nil, err
+	}
+	return siteType, result, nil
+}
+
+// StartFromPreview 使用缓存的解析结果开始下载，返回任务ID
+func (tm *TaskManager) StartFromPreview(previewID string, options StartOptions) (string, error) {
+	// 先检查选项，输入有误时保留预览，修改后可再次开始
+	if err := options.validate(); err != nil {
+		return "", err
+	}
+	entry, ok := tm.previews.take(previewID)
+	if !ok {
+		return "", fmt.Errorf("预览不存在或已过期: %s", previewID)
+	}
+
+	task, err := tm.addTask(entry.url, entry.result, options)
+	if err != nil {
+		return "", err
+	}
+	return task.ID, nil
+}
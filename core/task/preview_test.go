@@ -0,0 +1,54 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ImageMaster/core/crawler/parsers"
+	"ImageMaster/core/types"
+)
+
+// outputDirConfig 只提供输出目录的配置
+type outputDirConfig string
+
+func (d outputDirConfig) GetOutputDir() string { return string(d) }
+func (d outputDirConfig) GetProxy() string     { return "" }
+
+func TestStartFromPreviewKeepsPreviewOnInvalidOptions(t *testing.T) {
+	tm := NewTaskManager(Config{}, nil)
+	id, _ := tm.previews.put("https://example.com/g/1", &parsers.ParseResult{Name: "Gallery"})
+
+	for _, options := range []StartOptions{{Pages: "x-"}, {PageList: []int{0}}, {Collision: "replace"}} {
+		if _, err := tm.StartFromPreview(id, options); err == nil {
+			t.Errorf("StartFromPreview(%+v) should fail", options)
+		}
+	}
+	if _, ok := tm.previews.take(id); !ok {
+		t.Error("preview should be kept after invalid options")
+	}
+}
+
+func TestSampleSavePathUsesOptionsCollision(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "Gallery"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Gallery", "001.jpg"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tm := NewTaskManager(Config{}, nil)
+	tm.SetConfigManager(outputDirConfig(dir))
+	result := &parsers.ParseResult{Name: "Gallery", ImageURLs: []string{"https://example.com/1.jpg"}}
+
+	// 未指定时使用配置（默认合并），指定时按选项处理
+	if _, galleryPath, action, err := tm.sampleSavePath("generic", result, StartOptions{}); err != nil ||
+		action != types.CollisionMerge || galleryPath != filepath.ToSlash(filepath.Join(dir, "Gallery")) {
+		t.Errorf("default = %q %q %v", action, galleryPath, err)
+	}
+	savePath, galleryPath, action, err := tm.sampleSavePath("generic", result, StartOptions{Collision: types.CollisionRename})
+	if err != nil || action != types.CollisionRename || galleryPath != filepath.ToSlash(filepath.Join(dir, "Gallery (2)")) ||
+		savePath != galleryPath+"/001.jpg" {
+		t.Errorf("rename = %q %q %q %v", action, galleryPath, savePath, err)
+	}
+}
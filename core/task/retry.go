@@ -0,0 +1,172 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ImageMaster/core/crawler/parsers"
+	"ImageMaster/core/logger"
+	"ImageMaster/core/types"
+	"ImageMaster/core/utils"
+)
+
+// RetryFailed 只重新下载任务中失败的图片，任务状态与历史记录原地更新
+func (tm *TaskManager) RetryFailed(taskID string) error {
+	tm.mu.Lock()
+	task, exists := tm.tasks[taskID]
+	if !exists {
+		tm.mu.Unlock()
+		return fmt.Errorf("任务不存在: %s", taskID)
+	}
+	if tm.activeTasks[taskID] {
+		tm.mu.Unlock()
+		return fmt.Errorf("任务仍在进行中: %s", taskID)
+	}
+	failed := task.failedItems()
+	if len(failed) == 0 {
+		tm.mu.Unlock()
+		return fmt.Errorf("任务没有失败的图片: %s", taskID)
+	}
+
+	task.Status = string(types.StatusPending)
+	task.Error = ""
+	task.CompleteTime = time.Time{}
+	task.UpdatedAt = time.Now()
+	tm.activeTasks[taskID] = true
+	cancelChan := make(chan struct{})
+	tm.taskCancelMap[taskID] = cancelChan
+
+	logger.Info("重试任务 %s 中失败的 %d 张图片", taskID, len(failed))
+	tm.enqueueLocked(task, func() {
+		tm.runTask(taskID, cancelChan, types.StatusDownloading, func(ctx context.Context, task *DownloadTask, crawlerInstance types.ImageCrawler) (string, error) {
+			return "", tm.retryItems(ctx, task, crawlerInstance, failed)
+		})
+	})
+	tm.mu.Unlock()
+
+	tm.saveTaskStates()
+	return nil
+}
+
+// retryItems 先用原地址重试，仍失败的图片通过解析器重新解析地址后再试一次
+// 也用于继续下载暂停任务中未完成的图片
+func (tm *TaskManager) retryItems(ctx context.Context, task *DownloadTask, crawlerInstance types.ImageCrawler, items []types.DownloadItem) error {
+	tm.mu.RLock()
+	name := task.Name
+	headers := task.headers
+	tm.mu.RUnlock()
+
+	downloader := crawlerInstance.GetDownloader()
+	remaining := tm.downloadItems(task.ID, downloader, items, headers)
+
+	// 地址可能已过期，只重新解析失败的页
+	if len(remaining) > 0 && ctx.Err() == nil {
+		if parsedCrawler, ok := crawlerInstance.(parsers.ParsedCrawler); ok {
+			remaining = tm.reresolveItems(task, parsedCrawler, remaining)
+		}
+		// 解析过程可能改写任务名称
+		tm.UpdateTask(task.ID, func(task *DownloadTask) {
+			task.Name = name
+		})
+	}
+
+	// 进度按整个画廊计算
+	tm.UpdateTask(task.ID, func(task *DownloadTask) {
+		task.Progress.Total = len(task.items)
+		task.Progress.Current = len(task.items) - len(task.unfinishedItems())
+	})
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(remaining) > 0 {
+		return fmt.Errorf("仍有 %d 张图片下载失败", len(remaining))
+	}
+	return nil
+}
+
+// reresolveItems 按页码重新解析失败图片的地址并下载，返回仍失败的图片
+func (tm *TaskManager) reresolveItems(task *DownloadTask, crawlerInstance parsers.ParsedCrawler, items []types.DownloadItem) []types.DownloadItem {
+	pageNumbers := make([]int, 0, len(items))
+	for _, item := range items {
+		pageNumbers = append(pageNumbers, item.PageNumber)
+	}
+	pages, err := utils.NewPageSelection(pageNumbers)
+	if err != nil {
+		logger.Warn("重新解析失败: %v", err)
+		return items
+	}
+	if withPages, ok := crawlerInstance.(interface{ SetPageSelection(*utils.PageSelection) }); ok {
+		withPages.SetPageSelection(pages)
+	}
+
+	parsers.UpdateTaskStatus(crawlerInstance.GetDownloader(), types.StatusParsing, "")
+	result, err := crawlerInstance.Parse(task.URL)
+	if err != nil {
+		logger.Warn("重新解析失败: %v", err)
+		return items
+	}
+
+	urlByPage := make(map[int]string, len(result.ImageURLs))
+	for i, imgURL := range result.ImageURLs {
+		urlByPage[result.PageNumber(i)] = imgURL
+	}
+
+	var refreshed, unchanged []types.DownloadItem
+	for _, item := range items {
+		if newURL := urlByPage[item.PageNumber]; newURL != "" && newURL != item.URL {
+			item.URL = newURL
+			refreshed = append(refreshed, item)
+		} else {
+			unchanged = append(unchanged, item)
+		}
+	}
+	if len(refreshed) == 0 {
+		return items
+	}
+
+	logger.Info("重新解析得到 %d 张图片的新地址", len(refreshed))
+	// 请求头可能随地址一起更新，之后的重试使用新的请求头
+	tm.UpdateTask(task.ID, func(task *DownloadTask) {
+		task.headers = result.Headers
+	})
+	parsers.UpdateTaskStatus(crawlerInstance.GetDownloader(), types.StatusDownloading, "")
+	return append(unchanged, tm.downloadItems(task.ID, crawlerInstance.GetDownloader(), refreshed, result.Headers)...)
+}
+
+// downloadItems 下载指定图片，返回仍失败的图片
+func (tm *TaskManager) downloadItems(taskID string, downloader types.Downloader, items []types.DownloadItem, headers map[string]string) []types.DownloadItem {
+	urls := make([]string, len(items))
+	filePaths := make([]string, len(items))
+	for i, item := range items {
+		urls[i] = item.URL
+		filePaths[i] = item.FilePath
+	}
+
+	// 复制请求头，下载器可能会修改
+	batchHeaders := make(map[string]string, len(headers))
+	for k, v := range headers {
+		batchHeaders[k] = v
+	}
+	if _, err := downloader.BatchDownload(urls, filePaths, batchHeaders); err != nil {
+		logger.Warn("重试下载出错: %v", err)
+	}
+
+	// 以任务中记录的结果为准
+	retried := make(map[string]bool, len(items))
+	for _, item := range items {
+		retried[item.FilePath] = true
+	}
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	var remaining []types.DownloadItem
+	if task, exists := tm.tasks[taskID]; exists {
+		for _, item := range task.unfinishedItems() {
+			if retried[item.FilePath] {
+				remaining = append(remaining, item)
+			}
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,88 @@
+package task
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ImageMaster/core/types"
+)
+
+func TestRetryFailedAfterRestart(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var fetched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		fetched = append(fetched, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	gallery := filepath.Join(t.TempDir(), "gallery")
+	tm := newBusyTaskManager(dir)
+	task, err := tm.AddTaskWithOptions(server.URL+"/gallery", StartOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.mu.Lock()
+	task.Status = string(types.StatusFailed)
+	task.headers = map[string]string{"X-Token": "secret"}
+	task.setItems([]types.DownloadItem{
+		{PageNumber: 1, URL: server.URL + "/1.png", FilePath: gallery + "/001.png", Status: string(types.StatusCompleted)},
+		{PageNumber: 2, URL: server.URL + "/2.png", FilePath: gallery + "/002.png", Status: string(types.StatusFailed)},
+		{PageNumber: 3, URL: server.URL + "/3.png", FilePath: gallery + "/003.png", Status: string(types.StatusFailed)},
+	})
+	tm.removeFromQueueLocked(task.ID)
+	delete(tm.activeTasks, task.ID)
+	delete(tm.taskCancelMap, task.ID)
+	tm.mu.Unlock()
+	tm.saveTaskStates()
+
+	// 重启后请求头随任务状态一起恢复
+	restarted := NewTaskManager(Config{}, nil)
+	restarted.SetStateDir(dir)
+	if err := restarted.RetryFailed(task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	var status, errMsg string
+	for time.Now().Before(deadline) {
+		restarted.mu.RLock()
+		status, errMsg = restarted.tasks[task.ID].Status, restarted.tasks[task.ID].Error
+		restarted.mu.RUnlock()
+		if status == string(types.StatusCompleted) || status == string(types.StatusFailed) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if status != string(types.StatusCompleted) {
+		t.Fatalf("status = %s, error = %q", status, errMsg)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(fetched)
+	if strings.Join(fetched, ",") != "/2.png,/3.png" {
+		t.Errorf("fetched = %v, want only the failed pages", fetched)
+	}
+}
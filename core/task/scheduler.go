@@ -0,0 +1,200 @@
+package task
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"ImageMaster/core/types"
+)
+
+// 未配置时的默认并发限制
+const (
+	DefaultMaxActiveTasks  = 3 // 同时进行的最大任务数
+	DefaultMaxTasksPerSite = 2 // 同一站点类型同时进行的最大任务数
+)
+
+// queueEntry 排队中的任务
+type queueEntry struct {
+	taskID   string
+	siteType string
+	start    func() // 启动任务，在新的 goroutine 中执行
+}
+
+// queueConfig 获取当前的队列配置，配置修改后下次调度即生效
+func (tm *TaskManager) queueConfig() types.QueueConfig {
+	if provider, ok := tm.configManager.(types.QueueConfigProvider); ok {
+		return provider.GetQueueConfig()
+	}
+	return types.QueueConfig{
+		MaxActiveTasks:  DefaultMaxActiveTasks,
+		MaxTasksPerSite: DefaultMaxTasksPerSite,
+	}
+}
+
+// enqueueLocked 将任务加入队列并尝试调度，调用方需持有 tm.mu
+func (tm *TaskManager) enqueueLocked(task *DownloadTask, start func()) {
+	tm.pushQueueLocked(task, start)
+	tm.scheduleLocked()
+}
+
+// pushQueueLocked 将任务加入队列但不调度，调用方需持有 tm.mu
+// 队列按优先级从高到低排列，同优先级按加入顺序
+func (tm *TaskManager) pushQueueLocked(task *DownloadTask, start func()) {
+	tm.insertQueueLocked(&queueEntry{
+		taskID:   task.ID,
+		siteType: task.SiteType,
+		start:    start,
+	}, task.Priority)
+	tm.updateQueuePositionsLocked()
+}
+
+// insertQueueLocked 将任务插入到同优先级任务之后，调用方需持有 tm.mu
+func (tm *TaskManager) insertQueueLocked(entry *queueEntry, priority int) {
+	index := len(tm.queue)
+	for i, queued := range tm.queue {
+		if tm.priorityOf(queued.taskID) < priority {
+			index = i
+			break
+		}
+	}
+	tm.queue = slices.Insert(tm.queue, index, entry)
+}
+
+// priorityOf 获取任务优先级，调用方需持有 tm.mu
+func (tm *TaskManager) priorityOf(taskID string) int {
+	if task, exists := tm.tasks[taskID]; exists {
+		return task.Priority
+	}
+	return 0
+}
+
+// queueIndexLocked 获取任务在队列中的下标，不在队列中时返回 -1，调用方需持有 tm.mu
+func (tm *TaskManager) queueIndexLocked(taskID string) int {
+	return slices.IndexFunc(tm.queue, func(entry *queueEntry) bool {
+		return entry.taskID == taskID
+	})
+}
+
+// SetTaskPriority 设置任务优先级，数值越大越先执行
+// 排队中的任务按新的优先级调整位置
+func (tm *TaskManager) SetTaskPriority(taskID string, priority int) error {
+	tm.mu.Lock()
+	task, exists := tm.tasks[taskID]
+	if !exists {
+		tm.mu.Unlock()
+		return fmt.Errorf("任务不存在: %s", taskID)
+	}
+	task.Priority = priority
+	task.UpdatedAt = time.Now()
+	if index := tm.queueIndexLocked(taskID); index >= 0 {
+		entry := tm.queue[index]
+		tm.queue = slices.Delete(tm.queue, index, index+1)
+		tm.insertQueueLocked(entry, priority)
+		tm.scheduleLocked()
+	}
+	tm.mu.Unlock()
+
+	tm.saveTaskStates()
+	return nil
+}
+
+// MoveTask 将排队中的任务移动到指定位置（从 1 开始，超出范围时移到末尾）
+// 为保持队列按优先级排列，任务优先级会调整到与相邻任务一致的范围内
+func (tm *TaskManager) MoveTask(taskID string, position int) error {
+	tm.mu.Lock()
+	index := tm.queueIndexLocked(taskID)
+	if index < 0 {
+		tm.mu.Unlock()
+		return fmt.Errorf("任务未在排队: %s", taskID)
+	}
+
+	entry := tm.queue[index]
+	tm.queue = slices.Delete(tm.queue, index, index+1)
+	target := min(max(position-1, 0), len(tm.queue))
+	tm.queue = slices.Insert(tm.queue, target, entry)
+
+	// 优先级不能高于前一个任务，也不能低于后一个任务
+	task := tm.tasks[taskID]
+	if target > 0 {
+		task.Priority = min(task.Priority, tm.priorityOf(tm.queue[target-1].taskID))
+	}
+	if target < len(tm.queue)-1 {
+		task.Priority = max(task.Priority, tm.priorityOf(tm.queue[target+1].taskID))
+	}
+	task.UpdatedAt = time.Now()
+	tm.scheduleLocked()
+	tm.mu.Unlock()
+
+	tm.saveTaskStates()
+	return nil
+}
+
+// removeFromQueueLocked 将尚未开始的任务移出队列，调用方需持有 tm.mu
+func (tm *TaskManager) removeFromQueueLocked(taskID string) bool {
+	index := tm.queueIndexLocked(taskID)
+	if index < 0 {
+		return false
+	}
+	tm.queue = slices.Delete(tm.queue, index, index+1)
+	if task, exists := tm.tasks[taskID]; exists {
+		task.QueuePosition = 0
+	}
+	tm.scheduleLocked()
+	return true
+}
+
+// Schedule 按当前配置重新调度队列，用于并发限制调整后立即生效
+func (tm *TaskManager) Schedule() {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.scheduleLocked()
+}
+
+// scheduleLocked 按队列顺序（即优先级顺序）启动满足并发限制的任务，调用方需持有 tm.mu
+func (tm *TaskManager) scheduleLocked() {
+	cfg := tm.queueConfig()
+
+	perSite := make(map[string]int)
+	for _, siteType := range tm.running {
+		perSite[siteType]++
+	}
+
+	waiting := make([]*queueEntry, 0, len(tm.queue))
+	for _, entry := range tm.queue {
+		activeFull := cfg.MaxActiveTasks > 0 && len(tm.running) >= cfg.MaxActiveTasks
+		siteFull := cfg.MaxTasksPerSite > 0 && perSite[entry.siteType] >= cfg.MaxTasksPerSite
+		if activeFull || siteFull {
+			waiting = append(waiting, entry)
+			continue
+		}
+
+		tm.running[entry.taskID] = entry.siteType
+		perSite[entry.siteType]++
+		if task, exists := tm.tasks[entry.taskID]; exists {
+			task.QueuePosition = 0
+		}
+		go entry.start()
+	}
+	tm.queue = waiting
+	tm.updateQueuePositionsLocked()
+}
+
+// updateQueuePositionsLocked 更新排队位置，从 1 开始，调用方需持有 tm.mu
+func (tm *TaskManager) updateQueuePositionsLocked() {
+	for i, entry := range tm.queue {
+		if task, exists := tm.tasks[entry.taskID]; exists {
+			task.QueuePosition = i + 1
+		}
+	}
+}
+
+// GetQueuePosition 获取任务的排队位置，从 1 开始；未在排队时返回 0
+func (tm *TaskManager) GetQueuePosition(taskID string) int {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	if task, exists := tm.tasks[taskID]; exists {
+		return task.QueuePosition
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package task
+
+import (
+	"testing"
+)
+
+func TestScheduleRespectsLimits(t *testing.T) {
+	tm := NewTaskManager(Config{}, nil)
+	started := make(chan string, 10)
+
+	add := func(id, siteType string) {
+		task := &DownloadTask{ID: id, SiteType: siteType}
+		tm.tasks[id] = task
+		tm.enqueueLocked(task, func() { started <- id })
+	}
+
+	// 默认最多 3 个任务，同一站点最多 2 个
+	add("a1", "ehentai")
+	add("a2", "ehentai")
+	add("a3", "ehentai")
+	add("b1", "nhentai")
+	add("c1", "hitomi")
+
+	if len(tm.running) != 3 {
+		t.Fatalf("running = %d, want 3", len(tm.running))
+	}
+	for _, id := range []string{"a1", "a2", "b1"} {
+		if _, ok := tm.running[id]; !ok {
+			t.Errorf("%s should be running", id)
+		}
+	}
+	if got := tm.tasks["a3"].QueuePosition; got != 1 {
+		t.Errorf("a3 position = %d, want 1", got)
+	}
+	if got := tm.tasks["c1"].QueuePosition; got != 2 {
+		t.Errorf("c1 position = %d, want 2", got)
+	}
+
+	// 释放同站点的名额后，排在前面的 a3 先启动
+	delete(tm.running, "a1")
+	tm.scheduleLocked()
+	if _, ok := tm.running["a3"]; !ok {
+		t.Errorf("a3 should be running")
+	}
+	if got := tm.tasks["c1"].QueuePosition; got != 1 {
+		t.Errorf("c1 position = %d, want 1", got)
+	}
+
+	// 取消排队中的任务
+	if !tm.removeFromQueueLocked("c1") {
+		t.Fatalf("c1 should be removed from queue")
+	}
+	if len(tm.queue) != 0 || tm.tasks["c1"].QueuePosition != 0 {
+		t.Errorf("queue not empty after removal")
+	}
+
+	for i := 0; i < 4; i++ {
+		<-started
+	}
+}
+
+func TestQueuePriorityAndMove(t *testing.T) {
+	tm := NewTaskManager(Config{}, nil)
+	for _, id := range []string{"busy1", "busy2", "busy3"} {
+		tm.running[id] = id
+	}
+
+	add := func(id string, priority int) *DownloadTask {
+		task := &DownloadTask{ID: id, SiteType: "generic", Priority: priority}
+		tm.tasks[id] = task
+		tm.enqueueLocked(task, func() {})
+		return task
+	}
+	order := func() []string {
+		ids := make([]string, 0, len(tm.queue))
+		for _, entry := range tm.queue {
+			ids = append(ids, entry.taskID)
+		}
+		return ids
+	}
+	assertOrder := func(want ...string) {
+		t.Helper()
+		got := order()
+		if len(got) != len(want) {
+			t.Fatalf("queue = %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] || tm.tasks[want[i]].QueuePosition != i+1 {
+				t.Fatalf("queue = %v, want %v", got, want)
+			}
+		}
+	}
+
+	add("a", 0)
+	add("b", 0)
+	add("urgent", 5)
+	add("c", 0)
+	assertOrder("urgent", "a", "b", "c")
+
+	if err := tm.SetTaskPriority("c", 1); err != nil {
+		t.Fatal(err)
+	}
+	assertOrder("urgent", "c", "a", "b")
+
+	// 移到最前面时优先级提升到与原队首一致
+	if err := tm.MoveTask("b", 1); err != nil {
+		t.Fatal(err)
+	}
+	assertOrder("b", "urgent", "c", "a")
+	if got := tm.tasks["b"].Priority; got != 5 {
+		t.Errorf("b priority = %d, want 5", got)
+	}
+
+	// 移到末尾时优先级降低到与前一个任务一致
+	if err := tm.MoveTask("urgent", 10); err != nil {
+		t.Fatal(err)
+	}
+	assertOrder("b", "c", "a", "urgent")
+	if got := tm.tasks["urgent"].Priority; got != 0 {
+		t.Errorf("urgent priority = %d, want 0", got)
+	}
+
+	if err := tm.MoveTask("busy1", 1); err == nil {
+		t.Errorf("moving a running task should fail")
+	}
+}
@@ -0,0 +1,161 @@
+package task
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"ImageMaster/core/crawler/parsers"
+	"ImageMaster/core/logger"
+	"ImageMaster/core/types"
+)
+
+const (
+	taskStateFile   = "task_state.json" // 未完成任务的状态文件名，位于数据目录下
+	checkpointDelay = 2 * time.Second   // 任务更新后延迟保存状态，合并频繁的进度更新
+)
+
+// taskState 需要在重启后恢复的任务状态
+type taskState struct {
+	Task    *DownloadTask        `json:"task"`
+	Options StartOptions         `json:"options"`
+	Parsed  *parsers.ParseResult `json:"parsed,omitempty"`
+	Headers map[string]string    `json:"headers,omitempty"` // 下载图片的请求头，重启后重试失败图片时使用
+	Items   []types.DownloadItem `json:"items,omitempty"`
+}
+
+// SetStateDir 设置任务状态的保存目录，并恢复上次保存的任务
+// 中断的任务重新排队，在设置 Wails 上下文后开始执行
+func (tm *TaskManager) SetStateDir(dir string) {
+	tm.statePath = filepath.Join(dir, taskStateFile)
+	tm.loadTaskStates()
+}
+
+// shouldSaveState 判断任务是否需要保存状态：未结束的任务，以及可重试失败图片的任务
+// 已完成与已取消的任务只保留历史记录
+func shouldSaveState(task *DownloadTask) bool {
+	switch types.DownloadStatus(task.Status) {
+	case types.StatusPending, types.StatusParsing, types.StatusDownloading, types.StatusPaused:
+		return true
+	case types.StatusFailed:
+		return task.FailedCount > 0
+	}
+	return false
+}
+
+// scheduleCheckpoint 延迟保存任务状态，短时间内的多次更新只保存一次
+func (tm *TaskManager) scheduleCheckpoint() {
+	if tm.statePath == "" {
+		return
+	}
+	tm.stateMu.Lock()
+	defer tm.stateMu.Unlock()
+	if tm.checkpointPending {
+		return
+	}
+	tm.checkpointPending = true
+	time.AfterFunc(checkpointDelay, func() {
+		tm.stateMu.Lock()
+		tm.checkpointPending = false
+		tm.stateMu.Unlock()
+		tm.saveTaskStates()
+	})
+}
+
+// saveTaskStates 保存需要在重启后恢复的任务
+func (tm *TaskManager) saveTaskStates() {
+	if tm.statePath == "" {
+		return
+	}
+
+	tm.mu.RLock()
+	states := make([]taskState, 0)
+	for _, task := range tm.tasks {
+		if !shouldSaveState(task) {
+			continue
+		}
+		states = append(states, taskState{
+			Task:    task,
+			Options: task.options,
+			Parsed:  task.parsed,
+			Headers: task.headers,
+			Items:   task.items,
+		})
+	}
+	// 执行中的任务在前，排队中的按排队顺序，恢复时保持原有顺序
+	sort.SliceStable(states, func(i, j int) bool {
+		a, b := states[i].Task, states[j].Task
+		if a.QueuePosition != b.QueuePosition {
+			return a.QueuePosition < b.QueuePosition
+		}
+		return a.StartTime.Before(b.StartTime)
+	})
+	data, err := json.MarshalIndent(states, "", "  ")
+	tm.mu.RUnlock()
+	if err != nil {
+		logger.Error("Failed to serialize task state: %v", err)
+		return
+	}
+
+	// 先写临时文件再重命名，避免写入中断损坏状态文件
+	tm.stateMu.Lock()
+	defer tm.stateMu.Unlock()
+	tmpPath := tm.statePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		logger.Error("Failed to save task state: %v", err)
+		return
+	}
+	if err := os.Rename(tmpPath, tm.statePath); err != nil {
+		logger.Error("Failed to save task state: %v", err)
+	}
+}
+
+// loadTaskStates 恢复上次保存的任务
+// 暂停的任务保持暂停，失败的任务保留失败图片以便重试，其余中断的任务重新排队
+func (tm *TaskManager) loadTaskStates() {
+	data, err := os.ReadFile(tm.statePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error("Failed to read task state: %v", err)
+		}
+		return
+	}
+
+	var states []taskState
+	if err := json.Unmarshal(data, &states); err != nil {
+		logger.Error("Failed to parse task state: %v", err)
+		return
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	for _, state := range states {
+		task := state.Task
+		if task == nil || task.ID == "" {
+			continue
+		}
+		if _, exists := tm.tasks[task.ID]; exists {
+			continue
+		}
+		task.QueuePosition = 0
+		task.options = state.Options
+		task.parsed = state.Parsed
+		task.headers = state.Headers
+		task.setItems(state.Items)
+		tm.tasks[task.ID] = task
+
+		switch types.DownloadStatus(task.Status) {
+		case types.StatusPaused:
+			tm.activeTasks[task.ID] = true
+		case types.StatusFailed:
+			// 不再执行，可通过 RetryFailed 重试失败的图片
+		default:
+			// 从中断处继续，已下载的图片不会重复下载
+			task.Status = string(types.StatusPending)
+			tm.pushQueueLocked(task, tm.prepareContinueLocked(task))
+		}
+	}
+	logger.Debug("Restored %d tasks from task state", len(states))
+}
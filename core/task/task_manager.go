@@ -2,14 +2,18 @@ package task
 
 import (
 	"context"
+	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"ImageMaster/core/crawler"
+	"ImageMaster/core/crawler/parsers"
 	"ImageMaster/core/download"
 	"ImageMaster/core/types"
 	"ImageMaster/core/types/dto"
+	"ImageMaster/core/utils"
 
 	"github.com/google/uuid"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -26,6 +30,14 @@ type TaskManager struct {
 	historyStore  types.HistoryStore              // 历史记录存储
 	ctx           context.Context                 // Wails上下文
 	configManager types.ConfigProvider            // 配置管理器
+	previews      *previewCache                   // 预览解析结果缓存
+	queue         []*queueEntry                   // 等待执行的任务，按先后顺序
+	running       map[string]string               // 正在执行的任务及其站点类型
+	pausing       map[string]bool                 // 已请求暂停、等待停止的任务
+	statePath     string                          // 任务状态文件路径，为空时不保存
+	stateMu       sync.Mutex                      // 任务状态文件写入锁
+	// checkpointPending 是否已安排延迟保存任务状态，由 stateMu 保护
+	checkpointPending bool
 }
 
 // Config 任务管理器配置
@@ -42,6 +54,9 @@ func NewTaskManager(config Config, store types.HistoryStore) *TaskManager {
 		downloaders:   make(map[string]*download.Downloader),
 		defaultConfig: config.DownloaderConfig,
 		historyStore:  store,
+		previews:      newPreviewCache(),
+		running:       make(map[string]string),
+		pausing:       make(map[string]bool),
 	}
 }
 
@@ -55,13 +70,32 @@ func (tm *TaskManager) SetHistoryStore(store types.HistoryStore) {
 	tm.historyStore = store
 }
 
-// SetContext 设置Wails上下文
+// SetContext 设置Wails上下文，并开始执行恢复的任务
 func (tm *TaskManager) SetContext(ctx context.Context) {
 	tm.ctx = ctx
+	tm.Schedule()
 }
 
-// AddTask 添加下载任务并立即开始下载
+// AddTask 添加下载任务，有空闲名额时立即开始下载
 func (tm *TaskManager) AddTask(url string) *DownloadTask {
+	task, _ := tm.addTask(url, nil, StartOptions{})
+	return task
+}
+
+// AddTaskWithOptions 按选项添加下载任务，有空闲名额时立即开始下载
+func (tm *TaskManager) AddTaskWithOptions(url string, options StartOptions) (*DownloadTask, error) {
+	return tm.addTask(url, nil, options)
+}
+
+// addTask 添加任务，parsed 不为空时跳过解析直接下载
+func (tm *TaskManager) addTask(url string, parsed *parsers.ParseResult, options StartOptions) (*DownloadTask, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	pages, _ := options.PageSelection()
+	// 识别站点可能执行用户脚本，需在加锁前完成
+	siteType := parsers.DetectSiteType(url)
+
 	tm.mu.Lock()
 
 	// 创建新任务
@@ -72,6 +106,17 @@ func (tm *TaskManager) AddTask(url string) *DownloadTask {
 		Status:    string(types.StatusPending),
 		StartTime: now,
 		UpdatedAt: now,
+		Pages:     pages.String(),
+		SiteType:  siteType,
+		Priority:  options.Priority,
+		parsed:    parsed,
+		options:   options,
+	}
+	if parsed != nil {
+		task.Name = parsed.Name
+	}
+	if name := strings.TrimSpace(options.Name); name != "" {
+		task.Name = name
 	}
 
 	// 初始化进度
@@ -86,12 +131,15 @@ func (tm *TaskManager) AddTask(url string) *DownloadTask {
 	cancelChan := make(chan struct{})
 	tm.taskCancelMap[task.ID] = cancelChan
 
-	tm.mu.Unlock()
+	// 加入队列，超出并发限制时保持等待状态
+	tm.enqueueLocked(task, func() {
+		tm.executeTask(task.ID, cancelChan)
+	})
 
-	// 异步执行下载任务
-	go tm.executeTask(task.ID, cancelChan)
+	tm.mu.Unlock()
 
-	return task
+	tm.saveTaskStates()
+	return task, nil
 }
 
 // CrawlWebImages 从网页下载图片，返回任务ID
@@ -104,7 +152,9 @@ func (tm *TaskManager) CrawlWebImages(url string) string {
 // createDownloaderForTask 为任务创建专用的下载器实例
 func (tm *TaskManager) createDownloaderForTask(taskID string) *download.Downloader {
 	// 创建新的下载器实例
+	tm.mu.RLock()
 	newDownloader := download.NewDownloader(tm.defaultConfig)
+	tm.mu.RUnlock()
 
 	// 复制配置
 	if tm.configManager != nil {
@@ -116,13 +166,23 @@ func (tm *TaskManager) createDownloaderForTask(taskID string) *download.Download
 	newDownloader.SetTaskUpdater(taskUpdater)
 
 	// 保存到下载器映射
+	tm.mu.Lock()
 	tm.downloaders[taskID] = newDownloader
+	tm.mu.Unlock()
 
 	return newDownloader
 }
 
 // executeTask 执行下载任务
 func (tm *TaskManager) executeTask(taskID string, cancelChan chan struct{}) {
+	tm.runTask(taskID, cancelChan, types.StatusParsing, tm.crawlTask)
+}
+
+// taskRunner 任务的具体执行逻辑，返回保存路径（为空时保留原值）
+type taskRunner func(ctx context.Context, task *DownloadTask, crawlerInstance types.ImageCrawler) (string, error)
+
+// runTask 为任务准备上下文、下载器和爬虫，执行 run 后更新状态并持久化
+func (tm *TaskManager) runTask(taskID string, cancelChan chan struct{}, initialStatus types.DownloadStatus, run taskRunner) {
 	// 为该任务创建可取消的上下文
 	parent := tm.ctx
 	if parent == nil {
@@ -134,12 +194,40 @@ func (tm *TaskManager) executeTask(taskID string, cancelChan chan struct{}) {
 		<-cancelChan
 		cancel()
 	}()
+	// paused 为 true 表示因暂停而中断，在清理完成时才标记为暂停
+	// 避免继续下载在清理前启动新一轮执行，其取消通道与名额被本次清理删除
+	paused := false
 	defer func() {
 		tm.mu.Lock()
-		delete(tm.activeTasks, taskID)
+		// 暂停请求后又被取消的任务按取消处理
+		paused = paused && tm.pausing[taskID]
+		// 任务已停止，清除速度与剩余时间
+		if task, exists := tm.tasks[taskID]; exists {
+			if paused {
+				task.Status = string(types.StatusPaused)
+				task.Error = ""
+				task.UpdatedAt = time.Now()
+			}
+			task.Phase = task.Status
+			task.Speed = 0
+			task.ETA = 0
+		}
+		// 暂停的任务仍保留在活跃任务中
+		if task, exists := tm.tasks[taskID]; !exists || task.Status != string(types.StatusPaused) {
+			delete(tm.activeTasks, taskID)
+		}
+		delete(tm.pausing, taskID)
 		delete(tm.taskCancelMap, taskID)
 		delete(tm.downloaders, taskID)
+		// 释放名额，启动排队中的任务
+		delete(tm.running, taskID)
+		tm.scheduleLocked()
 		tm.mu.Unlock()
+		if paused {
+			tm.onTaskPaused(taskID)
+		} else {
+			tm.saveTaskStates()
+		}
 	}()
 
 	// 获取任务
@@ -149,11 +237,13 @@ func (tm *TaskManager) executeTask(taskID string, cancelChan chan struct{}) {
 		tm.mu.RUnlock()
 		return
 	}
+	siteType := task.SiteType
 	tm.mu.RUnlock()
 
-	// 更新任务状态为下载中
+	// 更新任务状态
 	tm.UpdateTask(taskID, func(task *DownloadTask) {
-		task.Status = string(types.StatusParsing)
+		task.Status = string(initialStatus)
+		task.Phase = string(initialStatus)
 		task.UpdatedAt = time.Now()
 	})
 
@@ -161,14 +251,10 @@ func (tm *TaskManager) executeTask(taskID string, cancelChan chan struct{}) {
 	downloader := tm.createDownloaderForTask(taskID)
 	// 传递上下文到下载器
 	downloader.SetContext(ctx)
+	downloader.SetSiteType(siteType)
 
 	// 创建爬虫工厂
-	crawlerFactory := crawler.NewCrawlerFactory()
-	if tm.configManager != nil {
-		crawlerFactory.SetConfigManager(tm.configManager)
-	}
-	// 传递上下文到爬虫工厂
-	crawlerFactory.SetContext(ctx)
+	crawlerFactory := tm.newCrawlerFactory(ctx)
 
 	// 检测网站类型并创建对应的爬虫
 	crawlerInstance, err := crawlerFactory.Create(task.URL)
@@ -190,16 +276,21 @@ func (tm *TaskManager) executeTask(taskID string, cancelChan chan struct{}) {
 		withCtx.SetContext(ctx)
 	}
 
-	// 设置输出目录
-	var outputDir string
-	if tm.configManager != nil {
-		outputDir = tm.configManager.GetOutputDir()
-	} else {
-		outputDir = "downloads"
+	// 执行任务
+	savePath, err := run(ctx, task, crawlerInstance)
+
+	// 因暂停而中断时保留进度，等待继续下载
+	tm.mu.Lock()
+	if tm.pausing[taskID] && err != nil {
+		paused = true
+		if savePath != "" {
+			task.SavePath = savePath
+		}
+		tm.mu.Unlock()
+		return
 	}
+	tm.mu.Unlock()
 
-	// 执行爬取
-	savePath, err := crawlerInstance.Crawl(task.URL, outputDir)
 	if err != nil {
 		// 如果是取消，标记为已取消，否则标记失败
 		tm.UpdateTask(taskID, func(task *DownloadTask) {
@@ -215,8 +306,14 @@ func (tm *TaskManager) executeTask(taskID string, cancelChan chan struct{}) {
 	} else {
 		// 下载成功
 		tm.UpdateTask(taskID, func(task *DownloadTask) {
-			task.Status = string(types.StatusCompleted)
-			task.SavePath = savePath
+			// 因目录已存在而跳过的任务保留跳过状态与说明
+			if task.Status != string(types.StatusSkipped) {
+				task.Status = string(types.StatusCompleted)
+				task.Error = ""
+			}
+			if savePath != "" {
+				task.SavePath = savePath
+			}
 			task.CompleteTime = time.Now()
 			task.UpdatedAt = time.Now()
 		})
@@ -235,6 +332,99 @@ func (tm *TaskManager) executeTask(taskID string, cancelChan chan struct{}) {
 	}
 }
 
+// crawlTask 解析并下载整个画廊
+func (tm *TaskManager) crawlTask(ctx context.Context, task *DownloadTask, crawlerInstance types.ImageCrawler) (string, error) {
+	// 设置输出目录
+	outputDir := tm.outputDir()
+
+	// 执行爬取
+	if parsedCrawler, ok := crawlerInstance.(parsers.ParsedCrawler); ok {
+		return outputDir, tm.crawlWithOptions(parsedCrawler, task, outputDir)
+	}
+	return crawlerInstance.Crawl(task.URL, outputDir)
+}
+
+// crawlWithOptions 按任务选项解析并下载；已有预览解析结果时跳过解析
+func (tm *TaskManager) crawlWithOptions(crawlerInstance parsers.ParsedCrawler, task *DownloadTask, outputDir string) error {
+	pages, err := task.options.PageSelection()
+	if err != nil {
+		return err
+	}
+	if withPages, ok := crawlerInstance.(interface{ SetPageSelection(*utils.PageSelection) }); ok {
+		withPages.SetPageSelection(pages)
+	}
+	if withNaming, ok := crawlerInstance.(interface {
+		SetNaming(string, types.NamingTemplates)
+	}); ok {
+		withNaming.SetNaming(task.SiteType, tm.namingTemplates(task.SiteType))
+	}
+	if withCollision, ok := crawlerInstance.(interface {
+		SetCollision(types.CollisionPolicy, string)
+	}); ok {
+		tm.mu.RLock()
+		galleryPath := task.GalleryPath
+		tm.mu.RUnlock()
+		withCollision.SetCollision(tm.collisionPolicy(task.options), galleryPath)
+	}
+
+	result := task.parsed
+	if result == nil {
+		if result, err = crawlerInstance.Parse(task.URL); err != nil {
+			return err
+		}
+	}
+
+	// 复制一份，避免修改预览缓存中的结果
+	parsed := *result
+	if name := strings.TrimSpace(task.options.Name); name != "" {
+		parsed.Name = strings.ReplaceAll(name, "/", "_")
+	}
+	// 保留解析结果与请求头，重试失败图片时复用
+	tm.UpdateTask(task.ID, func(task *DownloadTask) {
+		task.parsed = &parsed
+		task.headers = parsed.Headers
+	})
+	return crawlerInstance.CrawlParsed(&parsed, outputDir)
+}
+
+// outputDir 获取下载输出目录
+func (tm *TaskManager) outputDir() string {
+	if tm.configManager != nil {
+		return tm.configManager.GetOutputDir()
+	}
+	return "downloads"
+}
+
+// namingTemplates 获取站点类型对应的保存路径命名模板
+func (tm *TaskManager) namingTemplates(siteType string) types.NamingTemplates {
+	if provider, ok := tm.configManager.(types.NamingConfigProvider); ok {
+		return provider.GetNamingConfig().TemplatesFor(siteType)
+	}
+	return types.NamingTemplates{}
+}
+
+// collisionPolicy 获取画廊目录已存在时的处理方式，任务选项优先于配置
+func (tm *TaskManager) collisionPolicy(options StartOptions) types.CollisionPolicy {
+	if options.Collision != "" {
+		return options.Collision
+	}
+	if provider, ok := tm.configManager.(types.CollisionConfigProvider); ok {
+		return provider.GetCollisionPolicy()
+	}
+	return types.CollisionMerge
+}
+
+// newCrawlerFactory 创建带配置与上下文的爬虫工厂
+func (tm *TaskManager) newCrawlerFactory(ctx context.Context) *crawler.CrawlerFactory {
+	crawlerFactory := crawler.NewCrawlerFactory()
+	if tm.configManager != nil {
+		crawlerFactory.SetConfigManager(tm.configManager)
+	}
+	// 传递上下文到爬虫工厂
+	crawlerFactory.SetContext(ctx)
+	return crawlerFactory
+}
+
 // persistTaskToHistory 将任务持久化到历史记录
 func (tm *TaskManager) persistTaskToHistory(taskID string) {
 	tm.mu.RLock()
@@ -255,6 +445,7 @@ func (tm *TaskManager) UpdateTask(taskID string, updateFunc func(task *DownloadT
 	if task, exists := tm.tasks[taskID]; exists {
 		updateFunc(task)
 		task.UpdatedAt = time.Now()
+		tm.scheduleCheckpoint()
 	}
 }
 
@@ -269,32 +460,110 @@ func (tm *TaskManager) UpdateTaskProgress(taskID string, current, total int) {
 // CancelTask 取消任务
 func (tm *TaskManager) CancelTask(taskID string) bool {
 	tm.mu.Lock()
-	defer tm.mu.Unlock()
+	cancelled := tm.cancelTaskLocked(taskID)
+	tm.mu.Unlock()
+
+	if cancelled {
+		// 已取消的任务不再需要恢复
+		tm.saveTaskStates()
+	}
+	return cancelled
+}
+
+// cancelTaskLocked 取消进行中、排队中或已暂停的任务，调用方需持有 tm.mu
+func (tm *TaskManager) cancelTaskLocked(taskID string) bool {
+	task, exists := tm.tasks[taskID]
+	if !exists {
+		return false
+	}
 
-	if cancelChan, exists := tm.taskCancelMap[taskID]; exists {
+	if cancelChan, running := tm.taskCancelMap[taskID]; running {
 		close(cancelChan)
-		// 更新任务状态
+		// 尚未开始的任务直接出队并清理
+		if tm.removeFromQueueLocked(taskID) {
+			delete(tm.activeTasks, taskID)
+			delete(tm.taskCancelMap, taskID)
+		}
+	} else if tm.pausing[taskID] {
+		// 已请求暂停但尚未停止的任务，取消通道已关闭，清除暂停标记后由 runTask 按取消结束
+		delete(tm.pausing, taskID)
+	} else if task.Status == string(types.StatusPaused) {
+		// 暂停中的任务没有在执行，直接清理
+		delete(tm.activeTasks, taskID)
+	} else {
+		return false
+	}
+
+	// 更新任务状态
+	task.Status = string(types.StatusCancelled)
+	task.CompleteTime = time.Now()
+	task.UpdatedAt = time.Now()
+	// 立即持久化到历史
+	if tm.historyStore != nil {
+		dtoTask := ToDownloadTaskDTO(task)
+		tm.historyStore.AddDownloadRecord(dtoTask)
+	}
+	// 向前端发事件
+	if tm.ctx != nil {
+		runtime.EventsEmit(tm.ctx, "download:cancelled", map[string]interface{}{
+			"taskId": taskID,
+			"name":   task.Name,
+			"status": task.Status,
+		})
+	}
+	return true
+}
+
+// GetTaskItems 获取任务中每张图片的下载信息
+func (tm *TaskManager) GetTaskItems(taskID string) ([]types.DownloadItem, error) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	task, exists := tm.tasks[taskID]
+	if !exists {
+		return nil, fmt.Errorf("任务不存在: %s", taskID)
+	}
+	return append([]types.DownloadItem{}, task.items...), nil
+}
+
+// SetDownloadConcurrency 设置每个任务的并发下载数量，正在下载的任务立即生效
+func (tm *TaskManager) SetDownloadConcurrency(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("并发下载数量必须大于 0")
+	}
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.defaultConfig.Concurrency = n
+	for _, downloader := range tm.downloaders {
+		downloader.SetConcurrency(n)
+	}
+	return nil
+}
+
+// SetItemPriority 调整任务中尚未开始下载的图片的优先级，数值越大越先下载
+func (tm *TaskManager) SetItemPriority(taskID, url string, priority int) error {
+	tm.mu.RLock()
+	downloader, exists := tm.downloaders[taskID]
+	tm.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("任务未在下载: %s", taskID)
+	}
+	if !downloader.SetItemPriority(url, priority) {
+		return fmt.Errorf("图片不在等待下载: %s", url)
+	}
+	return nil
+}
+
+// GetGlobalSpeed 获取所有执行中任务的下载速度之和（字节/秒）
+func (tm *TaskManager) GetGlobalSpeed() float64 {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	var speed float64
+	for taskID := range tm.running {
 		if task, exists := tm.tasks[taskID]; exists {
-			task.Status = string(types.StatusCancelled)
-			task.CompleteTime = time.Now()
-			task.UpdatedAt = time.Now()
-			// 立即持久化到历史
-			if tm.historyStore != nil {
-				dtoTask := ToDownloadTaskDTO(task)
-				tm.historyStore.AddDownloadRecord(dtoTask)
-			}
-			// 向前端发事件
-			if tm.ctx != nil {
-				runtime.EventsEmit(tm.ctx, "download:cancelled", map[string]interface{}{
-					"taskId": taskID,
-					"name":   task.Name,
-					"status": task.Status,
-				})
-			}
+			speed += task.Speed
 		}
-		return true
 	}
-	return false
+	return speed
 }
 
 // GetTaskByID 根据ID获取任务
@@ -376,4 +645,5 @@ func (tm *TaskManager) ClearHistory() {
 		}
 	}
 	tm.mu.Unlock()
+	tm.saveTaskStates()
 }
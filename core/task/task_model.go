@@ -1,20 +1,106 @@
 package task
 
-import "time"
+import (
+	"time"
+
+	"ImageMaster/core/crawler/parsers"
+	"ImageMaster/core/types"
+)
 
 // DownloadTask 下载任务模型
 type DownloadTask struct {
-	ID           string    `json:"id"`           // 任务ID
-	URL          string    `json:"url"`          // 下载URL
-	Status       string    `json:"status"`       // 状态: pending, downloading, completed, failed
-	SavePath     string    `json:"savePath"`     // 保存路径
-	StartTime    time.Time `json:"startTime"`    // 开始时间
-	CompleteTime time.Time `json:"completeTime"` // 完成时间
-	UpdatedAt    time.Time `json:"updatedAt"`    // 更新时间
-	Error        string    `json:"error"`        // 错误信息
-	Name         string    `json:"name"`         // 任务名
-	Progress     struct {
+	ID              string    `json:"id"`              // 任务ID
+	URL             string    `json:"url"`             // 下载URL
+	Status          string    `json:"status"`          // 状态: pending, downloading, completed, failed
+	SavePath        string    `json:"savePath"`        // 保存路径
+	StartTime       time.Time `json:"startTime"`       // 开始时间
+	CompleteTime    time.Time `json:"completeTime"`    // 完成时间
+	UpdatedAt       time.Time `json:"updatedAt"`       // 更新时间
+	Error           string    `json:"error"`           // 错误信息
+	Name            string    `json:"name"`            // 任务名
+	Pages           string    `json:"pages"`           // 页码选择，为空表示全部
+	ResumedBytes    int64     `json:"resumedBytes"`    // 断点续传省下的字节数
+	FailedCount     int       `json:"failedCount"`     // 下载失败的图片数，可通过 RetryFailed 重试
+	SiteType        string    `json:"siteType"`        // 站点类型，用于按站点限制并发
+	QueuePosition   int       `json:"queuePosition"`   // 排队位置，从 1 开始，未排队时为 0
+	Priority        int       `json:"priority"`        // 优先级，数值越大越先执行，默认 0
+	Collision       string    `json:"collision"`       // 画廊目录已存在时实际采用的处理方式，目录不存在时为空
+	GalleryPath     string    `json:"galleryPath"`     // 实际使用的画廊目录
+	Phase           string    `json:"phase"`           // 当前阶段（解析/下载）
+	BytesDownloaded int64     `json:"bytesDownloaded"` // 本次下载已接收的字节数
+	BytesExpected   int64     `json:"bytesExpected"`   // 已开始下载的图片总字节数
+	Speed           float64   `json:"speed"`           // 平滑后的下载速度（字节/秒），未在下载时为 0
+	ETA             int64     `json:"eta"`             // 预计剩余秒数，未知时为 0
+	Progress        struct {
 		Current int `json:"current"` // 当前已下载项目数
 		Total   int `json:"total"`   // 总项目数
 	} `json:"progress"` // 下载进度
+
+	parsed  *parsers.ParseResult // 解析结果，预览时预先填入
+	headers map[string]string    // 下载图片时附加的请求头，重试失败图片时使用
+	options StartOptions         // 开始下载时的选项
+	items   []types.DownloadItem // 每张图片的下载信息
+}
+
+// setItems 设置全部图片，调用方需持有任务锁
+func (t *DownloadTask) setItems(items []types.DownloadItem) {
+	t.items = append([]types.DownloadItem(nil), items...)
+	for i := range t.items {
+		t.items[i].Index = i
+	}
+	t.countFailed()
+}
+
+// updateItem 按 FilePath 合并单张图片的状态或下载结果，调用方需持有任务锁
+func (t *DownloadTask) updateItem(item types.DownloadItem) {
+	for i := range t.items {
+		existing := &t.items[i]
+		if existing.FilePath != item.FilePath {
+			continue
+		}
+		existing.URL = item.URL
+		existing.Status = item.Status
+		existing.Attempts += item.Attempts
+		existing.Bytes = item.Bytes
+		if item.SavedPath != "" {
+			existing.SavedPath = item.SavedPath
+		}
+		existing.Error = item.Error
+		t.countFailed()
+		return
+	}
+	item.Index = len(t.items)
+	t.items = append(t.items, item)
+	t.countFailed()
+}
+
+// failedItems 返回失败图片的副本，调用方需持有任务锁
+func (t *DownloadTask) failedItems() []types.DownloadItem {
+	var failed []types.DownloadItem
+	for _, item := range t.items {
+		if item.Status == string(types.StatusFailed) {
+			failed = append(failed, item)
+		}
+	}
+	return failed
+}
+
+// unfinishedItems 返回尚未完成（失败或未开始）图片的副本，调用方需持有任务锁
+func (t *DownloadTask) unfinishedItems() []types.DownloadItem {
+	var unfinished []types.DownloadItem
+	for _, item := range t.items {
+		if item.Status != string(types.StatusCompleted) {
+			unfinished = append(unfinished, item)
+		}
+	}
+	return unfinished
+}
+
+func (t *DownloadTask) countFailed() {
+	t.FailedCount = 0
+	for _, item := range t.items {
+		if item.Status == string(types.StatusFailed) {
+			t.FailedCount++
+		}
+	}
 }
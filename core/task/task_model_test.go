@@ -0,0 +1,30 @@
+package task
+
+import (
+	"testing"
+
+	"ImageMaster/core/types"
+)
+
+func TestUpdateItemTracksState(t *testing.T) {
+	task := &DownloadTask{}
+	task.setItems([]types.DownloadItem{
+		{PageNumber: 1, URL: "u1", FilePath: "001.jpg", Status: string(types.StatusPending)},
+		{PageNumber: 3, URL: "u3", FilePath: "003.jpg", Status: string(types.StatusPending)},
+	})
+
+	task.updateItem(types.DownloadItem{URL: "u3", FilePath: "003.jpg", Status: string(types.StatusDownloading)})
+	if got := task.items[1]; got.Status != string(types.StatusDownloading) || got.Index != 1 || got.PageNumber != 3 {
+		t.Fatalf("in-flight item = %+v", got)
+	}
+
+	task.updateItem(types.DownloadItem{URL: "u3", FilePath: "003.jpg", Status: string(types.StatusFailed), Attempts: 3, Error: "状态码错误: 503"})
+	task.updateItem(types.DownloadItem{URL: "u3b", FilePath: "003.jpg", Status: string(types.StatusCompleted), Attempts: 1, Bytes: 2048})
+	got := task.items[1]
+	if got.Status != string(types.StatusCompleted) || got.Attempts != 4 || got.Bytes != 2048 || got.Error != "" || got.URL != "u3b" {
+		t.Errorf("completed item = %+v", got)
+	}
+	if task.FailedCount != 0 || len(task.unfinishedItems()) != 1 {
+		t.Errorf("failed = %d, unfinished = %d", task.FailedCount, len(task.unfinishedItems()))
+	}
+}
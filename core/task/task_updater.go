@@ -34,6 +34,7 @@ func (tu *TaskUpdater) UpdateTaskName(name string) {
 func (tu *TaskUpdater) UpdateTaskStatus(status string, errorMsg string) {
 	tu.manager.UpdateTask(tu.taskID, func(task *DownloadTask) {
 		task.Status = status
+		task.Phase = status
 		if errorMsg != "" {
 			task.Error = errorMsg
 		}
@@ -50,7 +51,32 @@ func (tu *TaskUpdater) UpdateTaskProgressWithDetails(progress types.ProgressDeta
 	tu.manager.UpdateTask(tu.taskID, func(task *DownloadTask) {
 		task.Progress.Current = progress.Current
 		task.Progress.Total = progress.Total
-		// 可以在这里扩展DownloadTask结构体来存储更多详细信息
+		task.Phase = progress.Phase
+		task.BytesDownloaded = progress.BytesDownloaded
+		task.BytesExpected = progress.BytesExpected
+		task.Speed = progress.BytesPerSecond
+		task.ETA = progress.ETASeconds
+	})
+}
+
+// AddResumedBytes 累加断点续传省下的字节数
+func (tu *TaskUpdater) AddResumedBytes(n int64) {
+	tu.manager.UpdateTask(tu.taskID, func(task *DownloadTask) {
+		task.ResumedBytes += n
+	})
+}
+
+// SetItems 设置本次要下载的全部图片
+func (tu *TaskUpdater) SetItems(items []types.DownloadItem) {
+	tu.manager.UpdateTask(tu.taskID, func(task *DownloadTask) {
+		task.setItems(items)
+	})
+}
+
+// UpdateItem 更新单张图片的下载结果
+func (tu *TaskUpdater) UpdateItem(item types.DownloadItem) {
+	tu.manager.UpdateTask(tu.taskID, func(task *DownloadTask) {
+		task.updateItem(item)
 	})
 }
 
@@ -74,6 +100,14 @@ func (tu *TaskUpdater) UpdateTaskField(field string, value interface{}) {
 			if errorMsg, ok := value.(string); ok {
 				task.Error = errorMsg
 			}
+		case "collision":
+			if action, ok := value.(string); ok {
+				task.Collision = action
+			}
+		case "galleryPath":
+			if path, ok := value.(string); ok {
+				task.GalleryPath = path
+			}
 		}
 	})
 }
@@ -0,0 +1,239 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// GenericCrawlerConfig 通用爬虫配置
+// 用于过滤页面中的图标、头像等小图片
+type GenericCrawlerConfig struct {
+	MinWidth  int   `json:"min_width"`  // 最小宽度（像素），0 表示不限制
+	MinHeight int   `json:"min_height"` // 最小高度（像素），0 表示不限制
+	MinBytes  int64 `json:"min_bytes"`  // 最小文件大小（字节），0 表示不限制
+}
+
+// GenericConfigProvider 通用爬虫配置提供者（可选接口）
+type GenericConfigProvider interface {
+	GetGenericCrawlerConfig() GenericCrawlerConfig
+}
+
+// 扩展目录类型，对应配置文件旁的 <配置名>-<类型> 目录
+const (
+	ExtensionRules   = "rules"   // 声明式站点规则
+	ExtensionScripts = "scripts" // 用户脚本解析器
+	ExtensionPlugins = "plugins" // 外部进程插件
+)
+
+// ExtensionDirProvider 扩展目录提供者（可选接口）
+type ExtensionDirProvider interface {
+	// GetExtensionDir 获取指定类型扩展所在目录
+	GetExtensionDir(kind string) string
+}
+
+// QueueConfig 下载队列配置
+type QueueConfig struct {
+	MaxActiveTasks  int `json:"max_active_tasks"`   // 同时进行的最大任务数，0 表示不限制
+	MaxTasksPerSite int `json:"max_tasks_per_site"` // 同一站点类型同时进行的最大任务数，0 表示不限制
+}
+
+// Validate 检查队列配置，并发数不能为负数
+func (c QueueConfig) Validate() error {
+	if c.MaxActiveTasks < 0 || c.MaxTasksPerSite < 0 {
+		return fmt.Errorf("并发数不能为负数")
+	}
+	return nil
+}
+
+// QueueConfigProvider 下载队列配置提供者（可选接口）
+type QueueConfigProvider interface {
+	GetQueueConfig() QueueConfig
+}
+
+// ValidationConfig 下载后图片校验配置
+type ValidationConfig struct {
+	// PlaceholderHashes 占位图（如流量超限提示图）的 SHA-256，十六进制，命中时视为下载失败并重试
+	PlaceholderHashes []string `json:"placeholder_hashes"`
+}
+
+// ValidationConfigProvider 图片校验配置提供者（可选接口）
+type ValidationConfigProvider interface {
+	GetValidationConfig() ValidationConfig
+}
+
+// RetryPolicy 下载重试策略
+// 暂时性错误（5xx、429、超时、连接重置等）按指数退避加随机抖动重试
+type RetryPolicy struct {
+	MaxRetries  int     `json:"max_retries"`   // 最大重试次数
+	BaseDelayMs int     `json:"base_delay_ms"` // 首次重试前的等待（毫秒），之后每次翻倍
+	MaxDelayMs  int     `json:"max_delay_ms"`  // 单次等待上限（毫秒），Retry-After 不受此限制
+	Jitter      float64 `json:"jitter"`        // 随机抖动比例，取值 0~1
+}
+
+// RetryConfig 下载重试配置
+type RetryConfig struct {
+	Default RetryPolicy            `json:"default"` // 默认策略
+	Sites   map[string]RetryPolicy `json:"sites"`   // 按站点类型覆盖默认策略，为 0 的字段沿用默认策略
+}
+
+// PolicyFor 获取站点类型对应的重试策略
+// 站点只配置部分字段时其余字段取默认值，避免缺少退避时间导致立即重试
+func (c RetryConfig) PolicyFor(siteType string) RetryPolicy {
+	policy := c.Default
+	if site, ok := c.Sites[siteType]; ok {
+		if site.MaxRetries != 0 {
+			policy.MaxRetries = site.MaxRetries
+		}
+		if site.BaseDelayMs != 0 {
+			policy.BaseDelayMs = site.BaseDelayMs
+		}
+		if site.MaxDelayMs != 0 {
+			policy.MaxDelayMs = site.MaxDelayMs
+		}
+		if site.Jitter != 0 {
+			policy.Jitter = site.Jitter
+		}
+	}
+	return policy
+}
+
+// RetryConfigProvider 下载重试配置提供者（可选接口）
+type RetryConfigProvider interface {
+	GetRetryConfig() RetryConfig
+}
+
+// RateLimitPolicy 单个站点的请求速率限制（令牌桶）
+type RateLimitPolicy struct {
+	RequestsPerSecond float64 `json:"requests_per_second"` // 每秒请求数，不大于 0 表示不限制
+	Burst             int     `json:"burst"`               // 允许的突发请求数
+}
+
+// RateLimitConfig 请求速率限制配置，按站点类型覆盖内置默认值
+type RateLimitConfig struct {
+	Sites map[string]RateLimitPolicy `json:"sites"`
+}
+
+// RateLimitConfigProvider 请求速率限制配置提供者（可选接口）
+type RateLimitConfigProvider interface {
+	GetRateLimitConfig() RateLimitConfig
+}
+
+// BandwidthRule 按时段的带宽限制，如夜间不限速
+type BandwidthRule struct {
+	Start     string `json:"start"`      // 开始时间，格式 HH:MM
+	End       string `json:"end"`        // 结束时间，格式 HH:MM，早于开始时间表示跨越午夜
+	LimitKBps int64  `json:"limit_kbps"` // 该时段的上限（KB/s），0 表示不限速
+}
+
+// BandwidthConfig 全局下载带宽限制配置
+type BandwidthConfig struct {
+	LimitKBps int64           `json:"limit_kbps"` // 默认上限（KB/s），0 表示不限速
+	Schedule  []BandwidthRule `json:"schedule"`   // 按时段覆盖默认上限，先匹配的优先
+}
+
+// LimitAt 获取指定时刻的带宽上限（字节/秒），0 表示不限速
+func (c BandwidthConfig) LimitAt(now time.Time) int64 {
+	limit := c.LimitKBps
+	minute := now.Hour()*60 + now.Minute()
+	for _, rule := range c.Schedule {
+		start, ok1 := parseClock(rule.Start)
+		end, ok2 := parseClock(rule.End)
+		if !ok1 || !ok2 {
+			continue
+		}
+		if (start <= end && minute >= start && minute < end) ||
+			(start > end && (minute >= start || minute < end)) {
+			limit = rule.LimitKBps
+			break
+		}
+	}
+	return max(limit, 0) * 1024
+}
+
+// Validate 检查带宽配置，时段的时间格式错误会被拒绝，避免误配置为不限速
+func (c BandwidthConfig) Validate() error {
+	if c.LimitKBps < 0 {
+		return fmt.Errorf("带宽上限不能为负数")
+	}
+	for i, rule := range c.Schedule {
+		if _, ok := parseClock(rule.Start); !ok {
+			return fmt.Errorf("第 %d 个时段的开始时间无效: %q，应为 HH:MM", i+1, rule.Start)
+		}
+		if _, ok := parseClock(rule.End); !ok {
+			return fmt.Errorf("第 %d 个时段的结束时间无效: %q，应为 HH:MM", i+1, rule.End)
+		}
+		if rule.LimitKBps < 0 {
+			return fmt.Errorf("第 %d 个时段的带宽上限不能为负数", i+1)
+		}
+	}
+	return nil
+}
+
+// parseClock 解析 HH:MM 为当天的分钟数
+func parseClock(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// BandwidthConfigProvider 带宽限制配置提供者（可选接口）
+type BandwidthConfigProvider interface {
+	GetBandwidthConfig() BandwidthConfig
+}
+
+// NamingTemplates 保存路径命名模板，为空时使用默认规则
+type NamingTemplates struct {
+	Folder string `json:"folder"` // 画廊目录模板，如 {site}/{artist}/[{id}] {title}
+	File   string `json:"file"`   // 图片文件名模板，如 {index:04}{ext}
+}
+
+// NamingConfig 保存路径命名配置
+type NamingConfig struct {
+	Default NamingTemplates            `json:"default"` // 默认模板
+	Sites   map[string]NamingTemplates `json:"sites"`   // 按站点类型覆盖，为空的字段沿用默认模板
+}
+
+// TemplatesFor 获取站点类型对应的命名模板
+func (c NamingConfig) TemplatesFor(siteType string) NamingTemplates {
+	templates := c.Default
+	if site, ok := c.Sites[siteType]; ok {
+		if site.Folder != "" {
+			templates.Folder = site.Folder
+		}
+		if site.File != "" {
+			templates.File = site.File
+		}
+	}
+	return templates
+}
+
+// NamingConfigProvider 命名模板配置提供者（可选接口）
+type NamingConfigProvider interface {
+	GetNamingConfig() NamingConfig
+}
+
+// CollisionPolicy 画廊目录已存在时的处理方式
+type CollisionPolicy string
+
+const (
+	CollisionMerge     CollisionPolicy = "merge"     // 合并：只下载缺失的图片（默认）
+	CollisionSkip      CollisionPolicy = "skip"      // 跳过：不下载
+	CollisionOverwrite CollisionPolicy = "overwrite" // 覆盖：重新下载全部图片
+	CollisionRename    CollisionPolicy = "rename"    // 重命名：下载到加序号的新目录，如 "name (2)"
+)
+
+// Valid 判断是否为已知的处理方式
+func (p CollisionPolicy) Valid() bool {
+	switch p {
+	case CollisionMerge, CollisionSkip, CollisionOverwrite, CollisionRename:
+		return true
+	}
+	return false
+}
+
+// CollisionConfigProvider 目录冲突处理方式提供者（可选接口）
+type CollisionConfigProvider interface {
+	GetCollisionPolicy() CollisionPolicy
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBandwidthConfigLimitAt(t *testing.T) {
+	cfg := BandwidthConfig{
+		LimitKBps: 4096,
+		Schedule: []BandwidthRule{
+			{Start: "23:00", End: "07:00", LimitKBps: 0},
+			{Start: "12:00", End: "13:00", LimitKBps: 1024},
+		},
+	}
+	cases := map[string]int64{
+		"10:00": 4096 * 1024,
+		"12:30": 1024 * 1024,
+		"23:30": 0,
+		"03:00": 0,
+		"07:00": 4096 * 1024,
+	}
+	for clock, want := range cases {
+		now, _ := time.Parse("15:04", clock)
+		if got := cfg.LimitAt(now); got != want {
+			t.Errorf("LimitAt(%s) = %d, want %d", clock, got, want)
+		}
+	}
+}
+
+func TestBandwidthConfigValidate(t *testing.T) {
+	valid := BandwidthConfig{Schedule: []BandwidthRule{{Start: "23:00", End: "07:00"}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	for _, rule := range []BandwidthRule{
+		{Start: "25:00", End: "07:00"},
+		{Start: "23:00", End: "7"},
+		{Start: "23:00", End: "07:00", LimitKBps: -1},
+	} {
+		cfg := BandwidthConfig{Schedule: []BandwidthRule{rule}}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", rule)
+		}
+	}
+}
+
+func TestRetryConfigPolicyFor(t *testing.T) {
+	cfg := RetryConfig{
+		Default: RetryPolicy{MaxRetries: 3, BaseDelayMs: 2000, MaxDelayMs: 60000, Jitter: 0.2},
+		Sites:   map[string]RetryPolicy{"ehentai": {MaxRetries: 5}},
+	}
+	want := RetryPolicy{MaxRetries: 5, BaseDelayMs: 2000, MaxDelayMs: 60000, Jitter: 0.2}
+	if got := cfg.PolicyFor("ehentai"); got != want {
+		t.Errorf("PolicyFor(ehentai) = %+v, want %+v", got, want)
+	}
+	if got := cfg.PolicyFor("nhentai"); got != cfg.Default {
+		t.Errorf("PolicyFor(nhentai) = %+v", got)
+	}
+}
@@ -15,7 +15,15 @@ type DownloadTaskDTO struct {
 	UpdatedAt    time.Time `json:"updatedAt"`
 	Error        string    `json:"error"`
 	Name         string    `json:"name"`
-	Progress     struct {
+	Collision    string    `json:"collision"`   // 画廊目录已存在时采用的处理方式
+	GalleryPath  string    `json:"galleryPath"` // 实际使用的画廊目录
+
+	Phase           string  `json:"phase"`           // 当前阶段（解析/下载）
+	BytesDownloaded int64   `json:"bytesDownloaded"` // 已接收的字节数
+	BytesExpected   int64   `json:"bytesExpected"`   // 已开始下载的图片总字节数
+	Speed           float64 `json:"speed"`           // 下载速度（字节/秒）
+	ETA             int64   `json:"eta"`             // 预计剩余秒数
+	Progress        struct {
 		Current int `json:"current"`
 		Total   int `json:"total"`
 	} `json:"progress"`
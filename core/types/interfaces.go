@@ -20,6 +20,25 @@ type TaskUpdater interface {
 	UpdateTaskField(field string, value interface{})
 	// UpdateTask 使用函数更新任务
 	UpdateTask(updateFunc func(task interface{}))
+	// AddResumedBytes 累加断点续传省下的字节数
+	AddResumedBytes(n int64)
+	// SetItems 设置本次要下载的全部图片
+	SetItems(items []DownloadItem)
+	// UpdateItem 按 FilePath 更新单张图片的状态或下载结果，Attempts 为本次新增的尝试次数
+	UpdateItem(item DownloadItem)
+}
+
+// DownloadItem 单张图片的下载信息
+type DownloadItem struct {
+	Index      int    `json:"index"`      // 在任务图片列表中的位置，从 0 开始
+	PageNumber int    `json:"pageNumber"` // 原始页码，从 1 开始
+	URL        string `json:"url"`        // 图片地址
+	FilePath   string `json:"filePath"`   // 保存路径
+	SavedPath  string `json:"savedPath"`  // 实际保存路径，扩展名按图片格式修正，完成前为空
+	Status     string `json:"status"`     // 状态，取值同 DownloadStatus
+	Attempts   int    `json:"attempts"`   // 累计尝试次数
+	Bytes      int64  `json:"bytes"`      // 已完成图片的文件大小
+	Error      string `json:"error"`      // 最近一次错误
 }
 
 // ProgressDetails 详细进度信息
@@ -31,6 +50,11 @@ type ProgressDetails struct {
 	CurrentItem string    `json:"currentItem"` // 当前处理项目
 	Phase       string    `json:"phase"`       // 当前阶段（解析/下载）
 	Timestamp   time.Time `json:"timestamp"`   // 时间戳
+
+	BytesDownloaded int64   `json:"bytesDownloaded"` // 已下载字节数
+	BytesExpected   int64   `json:"bytesExpected"`   // 已开始下载的图片总字节数
+	BytesPerSecond  float64 `json:"bytesPerSecond"`  // 平滑后的下载速度（字节/秒）
+	ETASeconds      int64   `json:"etaSeconds"`      // 预计剩余秒数，未知时为 0
 }
 
 // Downloader 下载器接口
@@ -87,6 +111,11 @@ type HistoryStore interface {
 	ClearDownloadHistory()
 }
 
+// DataDirProvider 数据目录提供者（可选接口），用于保存任务状态等应用数据
+type DataDirProvider interface {
+	GetDataDir() string
+}
+
 // DownloadStatus 表示下载任务状态
 type DownloadStatus string
 
@@ -97,4 +126,6 @@ const (
 	StatusCompleted   DownloadStatus = "completed"   // 下载完成
 	StatusFailed      DownloadStatus = "failed"      // 下载失败
 	StatusCancelled   DownloadStatus = "cancelled"   // 已取消
+	StatusPaused      DownloadStatus = "paused"      // 已暂停，可继续下载
+	StatusSkipped     DownloadStatus = "skipped"     // 画廊目录已存在，按设置跳过下载
 )
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	bandwidthChunk   = 32 * 1024              // 单次申请的最大字节数，保证并发下载之间大致公平
+	bandwidthMaxWait = 100 * time.Millisecond // 单次等待上限，限速调整后尽快生效
+)
+
+// Bandwidth 进程内共享的全局带宽限制器
+var Bandwidth = NewBandwidthLimiter()
+
+// BandwidthLimiter 按字节计的令牌桶，限制所有下载的总带宽
+type BandwidthLimiter struct {
+	mu        sync.Mutex
+	limitFunc func(now time.Time) int64 // 返回当前每秒字节数，不大于 0 表示不限速
+	tokens    float64
+	last      time.Time
+}
+
+// NewBandwidthLimiter 创建不限速的带宽限制器
+func NewBandwidthLimiter() *BandwidthLimiter {
+	return &BandwidthLimiter{last: time.Now()}
+}
+
+// SetLimit 设置固定的带宽上限（字节/秒），不大于 0 表示不限速
+func (l *BandwidthLimiter) SetLimit(bytesPerSecond int64) {
+	l.SetLimitFunc(func(time.Time) int64 { return bytesPerSecond })
+}
+
+// SetLimitFunc 设置按时间计算带宽上限的函数，用于读取实时配置或按时段限速
+func (l *BandwidthLimiter) SetLimitFunc(limitFunc func(now time.Time) int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limitFunc = limitFunc
+}
+
+// Limit 获取当前的带宽上限
+func (l *BandwidthLimiter) Limit() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limitAt(time.Now())
+}
+
+func (l *BandwidthLimiter) limitAt(now time.Time) int64 {
+	if l.limitFunc == nil {
+		return 0
+	}
+	return l.limitFunc(now)
+}
+
+// take 尝试取走 n 个字节的额度，不足时返回需要等待的时间
+func (l *BandwidthLimiter) take(n int, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limitAt(now)
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	if limit <= 0 {
+		l.tokens = 0
+		return 0
+	}
+
+	// 最多积累一秒的额度，避免空闲后瞬间突发
+	rate := float64(limit)
+	l.tokens = min(l.tokens+max(elapsed, 0)*rate, rate)
+	need := min(float64(n), rate)
+	if l.tokens >= need {
+		l.tokens -= float64(n)
+		return 0
+	}
+	return time.Duration((need - l.tokens) / rate * float64(time.Second))
+}
+
+// refund 归还申请后未实际读取的额度
+func (l *BandwidthLimiter) refund(n int) {
+	if n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limit := l.limitAt(time.Now()); limit > 0 {
+		l.tokens = min(l.tokens+float64(n), float64(limit))
+	}
+}
+
+// WaitN 等待 n 个字节的额度，上下文取消时返回错误
+// 每次最多等待 bandwidthMaxWait 后重新计算，限速调整后立即生效
+func (l *BandwidthLimiter) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for {
+		wait := l.take(n, time.Now())
+		if wait <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(min(wait, bandwidthMaxWait))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Reader 包装读取器，读取的数据计入带宽限制
+func (l *BandwidthLimiter) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &limitedReader{r: r, limiter: l, ctx: ctx}
+}
+
+// limitedReader 受带宽限制的读取器
+type limitedReader struct {
+	r       io.Reader
+	limiter *BandwidthLimiter
+	ctx     context.Context
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthChunk {
+		p = p[:bandwidthChunk]
+	}
+	if err := r.limiter.WaitN(r.ctx, len(p)); err != nil {
+		return 0, err
+	}
+	n, err := r.r.Read(p)
+	r.limiter.refund(len(p) - n)
+	return n, err
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestBandwidthLimiterTake(t *testing.T) {
+	l := NewBandwidthLimiter()
+	now := l.last
+
+	// 不限速时不等待
+	if wait := l.take(1<<20, now); wait != 0 {
+		t.Fatalf("unlimited: wait = %v", wait)
+	}
+
+	l.SetLimit(1000)
+	if wait := l.take(500, now.Add(time.Second)); wait != 0 {
+		t.Fatalf("within limit: wait = %v", wait)
+	}
+	if wait := l.take(1000, now.Add(time.Second)); wait != 500*time.Millisecond {
+		t.Errorf("over limit: wait = %v, want 500ms", wait)
+	}
+
+	// 调整上限后立即按新上限计算
+	l.SetLimit(0)
+	if wait := l.take(1000, now.Add(time.Second)); wait != 0 {
+		t.Errorf("after removing limit: wait = %v", wait)
+	}
+}
+
+func TestBandwidthLimiterReader(t *testing.T) {
+	l := NewBandwidthLimiter()
+	l.SetLimit(64 * 1024)
+	data := make([]byte, 96*1024)
+
+	// 首秒额度为空，读取 96 KB 约需 1.5 秒
+	start := time.Now()
+	got, err := io.ReadAll(l.Reader(context.Background(), bytes.NewReader(data)))
+	if err != nil || len(got) != len(data) {
+		t.Fatalf("ReadAll: %d bytes, %v", len(got), err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("read finished in %v, limit not applied", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.Reader(ctx, bytes.NewReader(data)).Read(make([]byte, len(data))); err != context.Canceled {
+		t.Errorf("read after cancel = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// FormatBytes 将字节数格式化为易读的形式，如 1.5 MB
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	units := []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for value >= unit && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
+
+// FormatSpeed 将每秒字节数格式化为速度，如 1.5 MB/s
+func FormatSpeed(bytesPerSecond float64) string {
+	return FormatBytes(int64(bytesPerSecond)) + "/s"
+}
+
+// FormatETA 将剩余秒数格式化为时长，如 1m20s；未知时返回空字符串
+func FormatETA(seconds int64) string {
+	if seconds <= 0 {
+		return ""
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
@@ -0,0 +1,26 @@
+package utils
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	cases := map[int64]string{
+		0:                  "0 B",
+		1023:               "1023 B",
+		1536:               "1.5 KB",
+		5 * 1024 * 1024:    "5.0 MB",
+		3 << 30:            "3.0 GB",
+		int64(1) << 50:     "1024.0 TB",
+		2*1024*1024 + 1024: "2.0 MB",
+	}
+	for n, want := range cases {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+	if got := FormatETA(80); got != "1m20s" {
+		t.Errorf("FormatETA(80) = %q", got)
+	}
+	if got := FormatETA(0); got != "" {
+		t.Errorf("FormatETA(0) = %q", got)
+	}
+}
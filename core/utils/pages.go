@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pageRange 页码区间，end 为 0 表示直到最后一页
+type pageRange struct {
+	start int
+	end   int
+}
+
+// PageSelection 页码选择，页码从 1 开始
+// nil 表示选择全部页
+type PageSelection struct {
+	ranges []pageRange
+}
+
+// ParsePageSelection 解析页码选择，如 "1-20,45,60-"
+// 空字符串返回 nil，表示全部
+func ParsePageSelection(spec string) (*PageSelection, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	var ranges []pageRange
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		startText, endText, isRange := strings.Cut(part, "-")
+		start, err := parsePageNumber(startText)
+		if err != nil {
+			return nil, fmt.Errorf("无效的页码 %q: %w", part, err)
+		}
+		if !isRange {
+			ranges = append(ranges, pageRange{start: start, end: start})
+			continue
+		}
+
+		end := 0
+		if strings.TrimSpace(endText) != "" {
+			if end, err = parsePageNumber(endText); err != nil {
+				return nil, fmt.Errorf("无效的页码 %q: %w", part, err)
+			}
+			if end < start {
+				return nil, fmt.Errorf("无效的页码 %q: 结束页小于起始页", part)
+			}
+		}
+		ranges = append(ranges, pageRange{start: start, end: end})
+	}
+
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+	return &PageSelection{ranges: ranges}, nil
+}
+
+// NewPageSelection 由明确的页码列表创建页码选择，空列表返回 nil
+func NewPageSelection(pages []int) (*PageSelection, error) {
+	if len(pages) == 0 {
+		return nil, nil
+	}
+	ranges := make([]pageRange, 0, len(pages))
+	for _, page := range pages {
+		if page < 1 {
+			return nil, fmt.Errorf("无效的页码 %d: 页码从 1 开始", page)
+		}
+		ranges = append(ranges, pageRange{start: page, end: page})
+	}
+	return &PageSelection{ranges: ranges}, nil
+}
+
+func parsePageNumber(text string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil {
+		return 0, fmt.Errorf("不是数字")
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("页码从 1 开始")
+	}
+	return n, nil
+}
+
+// Contains 判断页码是否被选中
+func (s *PageSelection) Contains(page int) bool {
+	if s == nil {
+		return true
+	}
+	for _, r := range s.ranges {
+		if page >= r.start && (r.end == 0 || page <= r.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回规范化的页码选择，如 "1-20,45,60-"
+func (s *PageSelection) String() string {
+	if s == nil {
+		return ""
+	}
+
+	ranges := append([]pageRange(nil), s.ranges...)
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start < ranges[j].start
+	})
+
+	// 合并重叠或相邻的区间
+	var merged []pageRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.end == 0 || r.start <= last.end+1 {
+				if last.end != 0 && (r.end == 0 || r.end > last.end) {
+					last.end = r.end
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+
+	parts := make([]string, 0, len(merged))
+	for _, r := range merged {
+		switch {
+		case r.end == 0:
+			parts = append(parts, fmt.Sprintf("%d-", r.start))
+		case r.end == r.start:
+			parts = append(parts, strconv.Itoa(r.start))
+		default:
+			parts = append(parts, fmt.Sprintf("%d-%d", r.start, r.end))
+		}
+	}
+	return strings.Join(parts, ",")
+}
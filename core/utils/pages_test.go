@@ -0,0 +1,51 @@
+package utils
+
+import "testing"
+
+func TestParsePageSelection(t *testing.T) {
+	sel, err := ParsePageSelection(" 1-3, 45 ,60- ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for page, want := range map[int]bool{1: true, 3: true, 4: false, 44: false, 45: true, 59: false, 60: true, 1000: true} {
+		if got := sel.Contains(page); got != want {
+			t.Errorf("Contains(%d) = %v, want %v", page, got, want)
+		}
+	}
+
+	if got := sel.String(); got != "1-3,45,60-" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestParsePageSelectionEmpty(t *testing.T) {
+	sel, err := ParsePageSelection("  ")
+	if err != nil || sel != nil {
+		t.Fatalf("expected nil selection, got %v, %v", sel, err)
+	}
+	if !sel.Contains(7) {
+		t.Error("nil selection should contain every page")
+	}
+}
+
+func TestParsePageSelectionInvalid(t *testing.T) {
+	for _, spec := range []string{"0", "a-3", "5-2", "-3", "1-b"} {
+		if _, err := ParsePageSelection(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestNewPageSelection(t *testing.T) {
+	sel, err := NewPageSelection([]int{5, 2, 3, 9})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := sel.String(); got != "2-3,5,9" {
+		t.Errorf("String() = %q", got)
+	}
+	if _, err := NewPageSelection([]int{0}); err == nil {
+		t.Error("expected error for page 0")
+	}
+}
@@ -6,17 +6,18 @@ toolchain go1.24.5
 
 require (
 	github.com/PuerkitoBio/goquery v1.10.3
+	github.com/andybalholm/cascadia v1.3.3
 	github.com/google/uuid v1.6.0
 	github.com/refraction-networking/utls v1.8.0
 	github.com/robertkrimen/otto v0.5.1
 	github.com/wailsapp/wails/v2 v2.10.1
+	golang.org/x/image v0.25.0
 	golang.org/x/net v0.42.0
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 )
 
 require (
 	github.com/andybalholm/brotli v1.0.6 // indirect
-	github.com/andybalholm/cascadia v1.3.3 // indirect
 	github.com/bep/debounce v1.2.1 // indirect
 	github.com/go-ole/go-ole v1.3.0 // indirect
 	github.com/godbus/dbus/v5 v5.1.0 // indirect
@@ -37,14 +37,15 @@ func main() {
 	// 创建历史记录API
 	historyAPI := history.NewAPI(AppName)
 
-	// 获取配置管理器
-	configAPI := config.NewAPI(AppName)
+	// 获取配置管理器，配置API与爬虫API共用同一份配置
+	configManager := config.NewManager(AppName)
+	configAPI := config.NewAPIWithManager(configManager)
 
 	// 创建图书馆API
 	libraryAPI := library.NewAPI(configAPI)
 
 	// 创建爬虫API（构造注入历史存储）
-	crawlerAPI := crawlerapi.NewCrawlerAPI(configAPI, historyAPI.GetStore())
+	crawlerAPI := crawlerapi.NewCrawlerAPI(configManager, historyAPI.GetStore())
 
 	// 创建应用
 	err := wails.Run(&options.App{